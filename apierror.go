@@ -0,0 +1,113 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	RequestIDHeader = "X-Request-Id"
+
+	maxErrorBodySize    = 64 << 10
+	maxErrorMessageSize = 256
+)
+
+// APIError describes a response of the CEEId service with a status other than 200.
+// It wraps ErrStatusNotOK.
+type APIError struct {
+	StatusCode int
+	// Code and Message are taken from the JSON error body, if any.
+	Code      string
+	Message   string
+	RequestID string
+	Endpoint  string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s, got: %v", ErrStatusNotOK, e.StatusCode)
+
+	var details []string
+	if e.Endpoint != "" {
+		details = append(details, "endpoint: "+e.Endpoint)
+	}
+
+	if e.Code != "" {
+		details = append(details, "code: "+e.Code)
+	}
+
+	if e.Message != "" {
+		details = append(details, "message: "+e.Message)
+	}
+
+	if e.RequestID != "" {
+		details = append(details, "request id: "+e.RequestID)
+	}
+
+	if len(details) == 0 {
+		return msg
+	}
+
+	return msg + " (" + strings.Join(details, ", ") + ")"
+}
+
+func (e *APIError) Unwrap() error {
+	return ErrStatusNotOK
+}
+
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Error   string `json:"error"`
+}
+
+// newAPIError builds an APIError from resp, consuming and closing its body.
+func newAPIError(resp *http.Response) *APIError {
+	defer drain(resp)
+
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get(RequestIDHeader),
+	}
+
+	if resp.Request != nil && resp.Request.URL != nil {
+		apiErr.Endpoint = resp.Request.URL.Path
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil || len(body) == 0 {
+		return apiErr
+	}
+
+	var eb errorBody
+	if json.Unmarshal(body, &eb) == nil {
+		apiErr.Code = eb.Code
+		apiErr.Message = eb.Message
+
+		if apiErr.Message == "" {
+			apiErr.Message = eb.Error
+		}
+
+		return apiErr
+	}
+
+	apiErr.Message = truncate(strings.TrimSpace(string(body)), maxErrorMessageSize)
+
+	return apiErr
+}
+
+// truncate cuts s to at most n bytes, without splitting a UTF-8 character.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n]
+}
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ceeideu/sdk/hem"
+)
+
+func TestXID_SendAPIError(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		handlerFunc http.HandlerFunc
+		want        APIError
+	}{
+		{
+			name: "json body",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(RequestIDHeader, "req-1")
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"code":"invalid_hem","message":"malformed hem"}`)
+			},
+			want: APIError{
+				StatusCode: http.StatusBadRequest,
+				Code:       "invalid_hem",
+				Message:    "malformed hem",
+				RequestID:  "req-1",
+				Endpoint:   XidGenerate,
+			},
+		},
+		{
+			name: "json error field",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"error":"invalid api key"}`)
+			},
+			want: APIError{
+				StatusCode: http.StatusUnauthorized,
+				Message:    "invalid api key",
+				Endpoint:   XidGenerate,
+			},
+		},
+		{
+			name: "text body",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+				fmt.Fprint(w, " bad gateway\n")
+			},
+			want: APIError{
+				StatusCode: http.StatusBadGateway,
+				Message:    "bad gateway",
+				Endpoint:   XidGenerate,
+			},
+		},
+		{
+			name: "long text body",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, strings.Repeat("x", maxErrorMessageSize-1)+"é")
+			},
+			want: APIError{
+				StatusCode: http.StatusBadRequest,
+				Message:    strings.Repeat("x", maxErrorMessageSize-1),
+				Endpoint:   XidGenerate,
+			},
+		},
+		{
+			name: "empty body",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			want: APIError{
+				StatusCode: http.StatusServiceUnavailable,
+				Endpoint:   XidGenerate,
+			},
+		},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ts := httptest.NewServer(test.handlerFunc)
+			defer ts.Close()
+
+			xidClient, _ := NewXID(ts.URL, XApiMockValue, WithHTTPClient(ts.Client()))
+			_, err := xidClient.Send(context.Background(), hem.FromEmail("test@com"))
+			if !errors.Is(err, ErrStatusNotOK) {
+				t.Fatalf("XID.Send() error = %v, want %v", err, ErrStatusNotOK)
+			}
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("XID.Send() error = %v, want *APIError", err)
+			}
+
+			if !reflect.DeepEqual(*apiErr, test.want) {
+				t.Errorf("APIError = %+v, want %+v", *apiErr, test.want)
+			}
+		})
+	}
+}
+
+func TestAPIError_Error(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		err  APIError
+		want string
+	}{
+		{
+			name: "status only",
+			err:  APIError{StatusCode: http.StatusBadRequest},
+			want: "http status code not OK error, got: 400",
+		},
+		{
+			name: "details",
+			err:  APIError{StatusCode: http.StatusBadRequest, Code: "c", Message: "m", RequestID: "r", Endpoint: "/e"},
+			want: "http status code not OK error, got: 400 (endpoint: /e, code: c, message: m, request id: r)",
+		},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if got := test.err.Error(); got != test.want {
+				t.Errorf("APIError.Error() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// jitter spreads d uniformly over [d-d*factor, d+d*factor].
+func jitter(d time.Duration, factor float64) time.Duration {
+	if d <= 0 || factor <= 0 {
+		return d
+	}
+
+	delta := float64(d) * factor
+
+	return time.Duration(float64(d) - delta + rand.Float64()*2*delta) //nolint:gosec // not security sensitive
+}
+
+// backoff returns base doubled per failed attempt, capped at maxDelay.
+func backoff(base, maxDelay time.Duration, attempt int) time.Duration {
+	d := base
+	for i := 0; i < attempt && d < maxDelay; i++ {
+		d *= 2
+	}
+
+	if maxDelay > 0 && d > maxDelay {
+		return maxDelay
+	}
+
+	return d
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
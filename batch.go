@@ -0,0 +1,265 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ceeideu/sdk/hem"
+	"github.com/ceeideu/sdk/xid"
+)
+
+const (
+	Batch            = "/batch"
+	XidGenerateBatch = XidGenerate + Batch
+	XidRefreshBatch  = XidRefresh + Batch
+
+	DefaultBatchConcurrency = 8
+	DefaultBatchSize        = 100
+)
+
+const (
+	batchUnknown int32 = iota
+	batchSupported
+	batchUnsupported
+)
+
+var (
+	ErrBatchItem     = errors.New("batch item error")
+	ErrBatchResponse = errors.New("batch response error")
+
+	errBatchUnsupported = errors.New("batch endpoint unsupported")
+)
+
+// BatchResult is the outcome of a single item of a batch call.
+type BatchResult[T any] struct {
+	Value T
+	Err   error
+}
+
+// WithBatchConcurrency bounds the number of concurrent requests made by a batch call.
+func WithBatchConcurrency(n int) func(*XID) {
+	return func(x *XID) {
+		x.batch.concurrency = n
+	}
+}
+
+// WithBatchSize sets the maximum number of items sent in a single batch request.
+func WithBatchSize(n int) func(*XID) {
+	return func(x *XID) {
+		x.batch.size = n
+	}
+}
+
+type batchConfig struct {
+	concurrency int
+	size        int
+
+	// support of the batch endpoints, detected on first use.
+	generate atomic.Int32
+	refresh  atomic.Int32
+}
+
+type batchReq[T any] struct {
+	Requests []T `json:"requests"`
+}
+
+type batchResp struct {
+	Responses []json.RawMessage `json:"responses"`
+}
+
+type batchItemErr struct {
+	Error string `json:"error"`
+}
+
+// SendBatch generates xIDs for all requests. A batch endpoint is used when the
+// service supports it, otherwise requests are sent one by one over a bounded
+// number of workers. Results are returned in input order.
+func (x *XID) SendBatch(ctx context.Context, hemReqs []hem.Request) []BatchResult[xid.Response] {
+	return runBatch(ctx, x, XidGenerateBatch, &x.batch.generate, hemReqs,
+		func(r hem.Request) error {
+			if r.Err != nil {
+				return fmt.Errorf("%s: %w", "hem request error", r.Err)
+			}
+
+			return nil
+		},
+		x.Send,
+	)
+}
+
+// RefreshXIDBatch refreshes all xIDs the same way SendBatch generates them.
+func (x *XID) RefreshXIDBatch(ctx context.Context, refreshReqs []xid.RefreshReq) []BatchResult[xid.RefreshResp] {
+	return runBatch(ctx, x, XidRefreshBatch, &x.batch.refresh, refreshReqs,
+		func(xid.RefreshReq) error { return nil },
+		x.RefreshXID,
+	)
+}
+
+func runBatch[Req, Resp any](
+	ctx context.Context,
+	x *XID,
+	path string,
+	support *atomic.Int32,
+	reqs []Req,
+	validate func(Req) error,
+	single func(context.Context, Req) (Resp, error),
+) []BatchResult[Resp] {
+	results := make([]BatchResult[Resp], len(reqs))
+
+	size := x.batch.size
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+
+	workers := x.batch.concurrency
+	if workers <= 0 {
+		workers = DefaultBatchConcurrency
+	}
+
+	chunks := make(chan int)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for start := range chunks {
+				end := min(start+size, len(reqs))
+				batchChunk(ctx, x, path, support, reqs[start:end], results[start:end], validate, single)
+			}
+		}()
+	}
+
+	for start := 0; start < len(reqs); start += size {
+		chunks <- start
+	}
+
+	close(chunks)
+	wg.Wait()
+
+	return results
+}
+
+func batchChunk[Req, Resp any](
+	ctx context.Context,
+	x *XID,
+	path string,
+	support *atomic.Int32,
+	reqs []Req,
+	results []BatchResult[Resp],
+	validate func(Req) error,
+	single func(context.Context, Req) (Resp, error),
+) {
+	// indexes of the valid requests sent to the service.
+	idx := make([]int, 0, len(reqs))
+	valid := make([]Req, 0, len(reqs))
+
+	for i, req := range reqs {
+		if err := validate(req); err != nil {
+			results[i].Err = err
+
+			continue
+		}
+
+		idx = append(idx, i)
+		valid = append(valid, req)
+	}
+
+	if len(valid) == 0 {
+		return
+	}
+
+	if support.Load() != batchUnsupported {
+		responses, err := sendBatch[Req](ctx, x, path, valid)
+
+		switch {
+		case errors.Is(err, errBatchUnsupported):
+			support.Store(batchUnsupported)
+		case err != nil:
+			for _, i := range idx {
+				results[i].Err = err
+			}
+
+			return
+		default:
+			support.Store(batchSupported)
+
+			for n, i := range idx {
+				results[i].Err = decodeBatchItem(responses[n], &results[i].Value)
+			}
+
+			return
+		}
+	}
+
+	for _, i := range idx {
+		results[i].Value, results[i].Err = single(ctx, reqs[i])
+	}
+}
+
+func sendBatch[Req any](ctx context.Context, x *XID, path string, reqs []Req) ([]json.RawMessage, error) {
+	_bytes, err := json.Marshal(batchReq[Req]{Requests: reqs})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMarshal, err)
+	}
+
+	resp, err := x.DoHTTPReq(ctx, http.MethodPost, x.baseURL.String()+path, _bytes)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && batchUnsupportedStatus(apiErr.StatusCode) {
+			return nil, errBatchUnsupported
+		}
+
+		return nil, fmt.Errorf("%w: %w", ErrDoHTTPReq, err)
+	}
+	defer drain(resp)
+
+	var br batchResp
+
+	err = x.decodeResponse(resp, &br)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(br.Responses) != len(reqs) {
+		return nil, fmt.Errorf("%w: got %d responses for %d requests", ErrBatchResponse, len(br.Responses), len(reqs))
+	}
+
+	return br.Responses, nil
+}
+
+func batchUnsupportedStatus(code int) bool {
+	return code == http.StatusNotFound || code == http.StatusMethodNotAllowed || code == http.StatusNotImplemented
+}
+
+func decodeBatchItem[Resp any](raw json.RawMessage, v *Resp) error {
+	var itemErr batchItemErr
+	if err := json.Unmarshal(raw, &itemErr); err != nil {
+		return fmt.Errorf("%w: %w", ErrDecode, err)
+	}
+
+	if itemErr.Error != "" {
+		return fmt.Errorf("%w: %s", ErrBatchItem, itemErr.Error)
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("%w: %w", ErrDecode, err)
+	}
+
+	if err := validate(v); err != nil {
+		return err
+	}
+
+	if s, ok := any(*v).(interface{ StatusOf() xid.StatusOf }); ok {
+		return statusError(s.StatusOf())
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ceeideu/sdk/hem"
+	"github.com/ceeideu/sdk/xid"
+)
+
+func TestXID_SendBatch(t *testing.T) {
+	t.Parallel()
+
+	batchHandler := func(w http.ResponseWriter, r *http.Request) {
+		var req batchReq[hem.Request]
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		resp := batchResp{}
+		for _, hemReq := range req.Requests {
+			item, _ := json.Marshal(xid.Response{Value: "xid-" + hemReq.Value})
+			if hemReq.Value == "bad" {
+				item = json.RawMessage(`{"error":"rejected"}`)
+			}
+			resp.Responses = append(resp.Responses, item)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+	singleHandler := func(w http.ResponseWriter, r *http.Request) {
+		var hemReq hem.Request
+		_ = json.NewDecoder(r.Body).Decode(&hemReq)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(xid.Response{Value: "xid-" + hemReq.Value})
+	}
+
+	reqs := []hem.Request{
+		{Type: xid.Hex, Value: "a"},
+		{Err: hem.ErrLen},
+		{Type: xid.Hex, Value: "b"},
+		{Type: xid.Hex, Value: "c"},
+	}
+
+	tests := []struct {
+		name          string
+		batchEndpoint bool
+		reqs          []hem.Request
+		want          []string
+		wantErr       []bool
+		wantBatchHits int32
+	}{
+		{
+			name:          "batch endpoint",
+			batchEndpoint: true,
+			reqs:          append(reqs, hem.Request{Type: xid.Hex, Value: "bad"}),
+			want:          []string{"xid-a", "", "xid-b", "xid-c", ""},
+			wantErr:       []bool{false, true, false, false, true},
+			wantBatchHits: 3,
+		},
+		{
+			name:          "fan out",
+			batchEndpoint: false,
+			reqs:          reqs,
+			want:          []string{"xid-a", "", "xid-b", "xid-c"},
+			wantErr:       []bool{false, true, false, false},
+			wantBatchHits: 1,
+		},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var batchHits, inFlight, maxInFlight atomic.Int32
+			mux := http.NewServeMux()
+			mux.HandleFunc(XidGenerateBatch, func(w http.ResponseWriter, r *http.Request) {
+				batchHits.Add(1)
+				if !test.batchEndpoint {
+					w.WriteHeader(http.StatusNotFound)
+
+					return
+				}
+				batchHandler(w, r)
+			})
+			mux.HandleFunc(XidGenerate, func(w http.ResponseWriter, r *http.Request) {
+				n := inFlight.Add(1)
+				defer inFlight.Add(-1)
+				for {
+					m := maxInFlight.Load()
+					if n <= m || maxInFlight.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				singleHandler(w, r)
+			})
+			ts := httptest.NewServer(mux)
+			defer ts.Close()
+
+			xidClient, _ := NewXID(ts.URL, XApiMockValue, WithHTTPClient(ts.Client()),
+				WithBatchSize(2), WithBatchConcurrency(1))
+
+			got := xidClient.SendBatch(context.Background(), test.reqs)
+			if len(got) != len(test.want) {
+				t.Fatalf("XID.SendBatch() returned %d results, want %d", len(got), len(test.want))
+			}
+			for i := range got {
+				if got[i].Value.Value != test.want[i] || (got[i].Err != nil) != test.wantErr[i] {
+					t.Errorf("XID.SendBatch()[%d] = %+v, want %v, wantErr %v", i, got[i], test.want[i], test.wantErr[i])
+				}
+			}
+			if batchHits.Load() != test.wantBatchHits {
+				t.Errorf("batch endpoint hits = %d, want %d", batchHits.Load(), test.wantBatchHits)
+			}
+			if maxInFlight.Load() > 1 {
+				t.Errorf("max concurrent requests = %d, want at most 1", maxInFlight.Load())
+			}
+		})
+	}
+}
+
+func TestXID_RefreshXIDBatch(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	xidClient, _ := NewXID(ts.URL, XApiMockValue, WithHTTPClient(ts.Client()))
+
+	got := xidClient.RefreshXIDBatch(context.Background(), []xid.RefreshReq{{XID: "a"}, {XID: "b"}})
+	for i := range got {
+		if !errors.Is(got[i].Err, ErrStatusNotOK) {
+			t.Errorf("XID.RefreshXIDBatch()[%d] error = %v, want %v", i, got[i].Err, ErrStatusNotOK)
+		}
+	}
+}
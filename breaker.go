@@ -0,0 +1,240 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	BreakerClosed = BreakerState(0)
+	BreakerOpen   = BreakerState(1)
+	// BreakerHalfOpen lets a limited number of trial calls through after the open timeout.
+	BreakerHalfOpen = BreakerState(2)
+
+	defaultBreakerThreshold   = 5
+	defaultBreakerOpenTimeout = 10 * time.Second
+	defaultBreakerHalfOpen    = 1
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+type BreakerState byte
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerConfig configures the circuit breakers kept per endpoint path.
+// Transport errors, 5xx and 429 responses count as failures.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures opening the circuit.
+	FailureThreshold int
+	// EndpointThresholds overrides FailureThreshold for the given endpoint paths, e.g. XidGenerate.
+	EndpointThresholds map[string]int
+	// OpenTimeout is how long the circuit stays open before trial calls are let through.
+	OpenTimeout time.Duration
+	// HalfOpenMaxCalls is the number of trial calls allowed while half-open.
+	HalfOpenMaxCalls int
+}
+
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		FailureThreshold: defaultBreakerThreshold,
+		OpenTimeout:      defaultBreakerOpenTimeout,
+		HalfOpenMaxCalls: defaultBreakerHalfOpen,
+	}
+}
+
+// WithCircuitBreaker makes calls to an endpoint fail fast with ErrCircuitOpen
+// after repeated failures.
+func WithCircuitBreaker(cfg CircuitBreakerConfig) func(*XID) {
+	return func(x *XID) {
+		x.breakers = &breakers{cfg: cfg, byPath: map[string]*breaker{}}
+	}
+}
+
+// CircuitState returns the state of the circuit breaker of the endpoint path.
+// Paths of fallback endpoints are prefixed with their base URL.
+func (x *XID) CircuitState(endpoint string) BreakerState {
+	if x.breakers == nil {
+		return BreakerClosed
+	}
+
+	br, ok := x.breakers.lookup(endpoint)
+	if !ok {
+		return BreakerClosed
+	}
+
+	return br.current(x.clock())
+}
+
+type breakers struct {
+	cfg    CircuitBreakerConfig
+	byPath map[string]*breaker
+
+	m sync.Mutex
+}
+
+// lookup returns the breaker of key, if a call was made through it.
+func (b *breakers) lookup(key string) (*breaker, bool) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	br, ok := b.byPath[key]
+
+	return br, ok
+}
+
+func (b *breakers) get(key, path string) *breaker {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	if br, ok := b.byPath[key]; ok {
+		return br
+	}
+
+	threshold := b.cfg.FailureThreshold
+	if t, ok := b.cfg.EndpointThresholds[path]; ok {
+		threshold = t
+	}
+
+	br := &breaker{
+		threshold:   max(threshold, 1),
+		openTimeout: b.cfg.OpenTimeout,
+		halfOpenMax: max(b.cfg.HalfOpenMaxCalls, 1),
+	}
+	b.byPath[key] = br
+
+	return br
+}
+
+// allow reports whether a call to the endpoint path may be made through the
+// breaker of key. Without breakers configured every call is allowed, through
+// a breaker recording nothing.
+func (b *breakers) allow(key, path string, now time.Time) (*breaker, error) {
+	if b == nil {
+		return &breaker{noop: true}, nil
+	}
+
+	br := b.get(key, path)
+	if !br.allow(now) {
+		return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, key)
+	}
+
+	return br, nil
+}
+
+type breaker struct {
+	threshold   int
+	openTimeout time.Duration
+	halfOpenMax int
+	// noop breakers record nothing.
+	noop bool
+
+	state         BreakerState
+	failures      int
+	openedAt      time.Time
+	halfOpenCalls int
+
+	m sync.Mutex
+}
+
+func (b *breaker) current(now time.Time) BreakerState {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	if b.state == BreakerOpen && now.Sub(b.openedAt) >= b.openTimeout {
+		return BreakerHalfOpen
+	}
+
+	return b.state
+}
+
+func (b *breaker) allow(now time.Time) bool {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	switch b.state {
+	case BreakerClosed:
+		return true
+	case BreakerOpen:
+		if now.Sub(b.openedAt) < b.openTimeout {
+			return false
+		}
+
+		b.state = BreakerHalfOpen
+		b.halfOpenCalls = 0
+	case BreakerHalfOpen:
+	}
+
+	if b.halfOpenCalls >= b.halfOpenMax {
+		return false
+	}
+
+	b.halfOpenCalls++
+
+	return true
+}
+
+func (b *breaker) record(success bool, now time.Time) {
+	if b.noop {
+		return
+	}
+
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	if success {
+		b.state = BreakerClosed
+		b.failures = 0
+
+		return
+	}
+
+	b.failures++
+
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = now
+	}
+}
+
+// release returns a trial call slot of a half-open breaker without counting
+// the call, e.g. when it was canceled by the caller.
+func (b *breaker) release() {
+	if b.noop {
+		return
+	}
+
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	if b.state == BreakerHalfOpen && b.halfOpenCalls > 0 {
+		b.halfOpenCalls--
+	}
+}
+
+// observe records the outcome of a call. Calls canceled by the caller are not
+// held against the endpoint, calls that ran out of time are.
+func (b *breaker) observe(ctx context.Context, resp *http.Response, err error, now time.Time) {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		b.release()
+
+		return
+	}
+
+	b.record(err == nil && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < http.StatusInternalServerError, now)
+}
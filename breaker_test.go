@@ -0,0 +1,220 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_breaker(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	type step struct {
+		at        time.Duration
+		success   bool
+		wantAllow bool
+		wantState BreakerState
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "opens after threshold",
+			steps: []step{
+				{at: 0, success: false, wantAllow: true, wantState: BreakerClosed},
+				{at: 0, success: false, wantAllow: true, wantState: BreakerOpen},
+				{at: time.Second, wantAllow: false, wantState: BreakerOpen},
+			},
+		},
+		{
+			name: "success resets failures",
+			steps: []step{
+				{at: 0, success: false, wantAllow: true, wantState: BreakerClosed},
+				{at: 0, success: true, wantAllow: true, wantState: BreakerClosed},
+				{at: 0, success: false, wantAllow: true, wantState: BreakerClosed},
+			},
+		},
+		{
+			name: "half-open trial success closes",
+			steps: []step{
+				{at: 0, success: false, wantAllow: true, wantState: BreakerClosed},
+				{at: 0, success: false, wantAllow: true, wantState: BreakerOpen},
+				{at: time.Minute, success: true, wantAllow: true, wantState: BreakerClosed},
+			},
+		},
+		{
+			name: "half-open trial failure reopens",
+			steps: []step{
+				{at: 0, success: false, wantAllow: true, wantState: BreakerClosed},
+				{at: 0, success: false, wantAllow: true, wantState: BreakerOpen},
+				{at: time.Minute, success: false, wantAllow: true, wantState: BreakerOpen},
+				{at: time.Minute + time.Second, wantAllow: false, wantState: BreakerOpen},
+			},
+		},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			br := &breaker{threshold: 2, openTimeout: 30 * time.Second, halfOpenMax: 1}
+			for i, s := range test.steps {
+				now := start.Add(s.at)
+				allowed := br.allow(now)
+				if allowed != s.wantAllow {
+					t.Fatalf("step %d: allow() = %v, want %v", i, allowed, s.wantAllow)
+				}
+				if allowed {
+					br.record(s.success, now)
+				}
+				if got := br.current(now); got != s.wantState {
+					t.Errorf("step %d: state = %v, want %v", i, got, s.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestXID_CircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	xidClient, _ := NewXID(ts.URL, XApiMockValue, WithHTTPClient(ts.Client()), WithCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold:   3,
+		EndpointThresholds: map[string]int{KeysRefresh: 1},
+		OpenTimeout:        time.Minute,
+	}))
+
+	for i := 0; i < 3; i++ {
+		_, err := xidClient.DoHTTPReq(context.Background(), http.MethodPost, ts.URL+XidGenerate, nil)
+		if !errors.Is(err, ErrStatusNotOK) {
+			t.Fatalf("call %d: DoHTTPReq() error = %v, want %v", i, err, ErrStatusNotOK)
+		}
+	}
+
+	_, err := xidClient.DoHTTPReq(context.Background(), http.MethodPost, ts.URL+XidGenerate, nil)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("DoHTTPReq() error = %v, want %v", err, ErrCircuitOpen)
+	}
+
+	if err := xidClient.Refresh(context.Background()); !errors.Is(err, ErrStatusNotOK) {
+		t.Errorf("Refresh() error = %v, want %v", err, ErrStatusNotOK)
+	}
+
+	if err := xidClient.Refresh(context.Background()); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Refresh() error = %v, want %v", err, ErrCircuitOpen)
+	}
+
+	if hits.Load() != 4 {
+		t.Errorf("hits = %d, want 4", hits.Load())
+	}
+
+	if got := xidClient.CircuitState(XidRefresh); got != BreakerClosed {
+		t.Errorf("CircuitState(%s) = %v, want %v", XidRefresh, got, BreakerClosed)
+	}
+}
+
+func TestXID_CircuitBreakerTimeout(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	timeouts := DefaultTimeouts()
+	timeouts.Generate = 20 * time.Millisecond
+
+	xidClient, _ := NewXID(ts.URL, XApiMockValue, WithHTTPClient(ts.Client()), WithTimeouts(timeouts),
+		WithCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute}))
+
+	for i := 0; i < 2; i++ {
+		_, err := xidClient.DoHTTPReq(context.Background(), http.MethodPost, ts.URL+XidGenerate, nil)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("call %d: DoHTTPReq() error = %v, want %v", i, err, context.DeadlineExceeded)
+		}
+	}
+
+	if got := xidClient.CircuitState(XidGenerate); got != BreakerOpen {
+		t.Errorf("CircuitState(%s) = %v, want %v", XidGenerate, got, BreakerOpen)
+	}
+
+	_, err := xidClient.DoHTTPReq(context.Background(), http.MethodPost, ts.URL+XidGenerate, nil)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("DoHTTPReq() error = %v, want %v", err, ErrCircuitOpen)
+	}
+
+	if hits.Load() != 2 {
+		t.Errorf("hits = %d, want 2", hits.Load())
+	}
+}
+
+func TestXID_CircuitBreakerCanceled(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	xidClient, _ := NewXID(ts.URL, XApiMockValue, WithHTTPClient(ts.Client()),
+		WithCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	if _, err := xidClient.DoHTTPReq(ctx, http.MethodPost, ts.URL+XidGenerate, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("DoHTTPReq() error = %v, want %v", err, context.Canceled)
+	}
+
+	if got := xidClient.CircuitState(XidGenerate); got != BreakerClosed {
+		t.Errorf("CircuitState(%s) = %v, want %v", XidGenerate, got, BreakerClosed)
+	}
+}
+
+func TestXID_CircuitStateUnknown(t *testing.T) {
+	t.Parallel()
+
+	xidClient, _ := NewXID("http://localhost", XApiMockValue, WithCircuitBreaker(DefaultCircuitBreakerConfig()))
+
+	for i := 0; i < 3; i++ {
+		if got := xidClient.CircuitState("/unknown/" + strconv.Itoa(i)); got != BreakerClosed {
+			t.Errorf("CircuitState() = %v, want %v", got, BreakerClosed)
+		}
+	}
+
+	if n := len(xidClient.breakers.byPath); n != 0 {
+		t.Errorf("breakers = %d, want 0", n)
+	}
+}
+
+func Test_breakers_allowDisabled(t *testing.T) {
+	t.Parallel()
+
+	var b *breakers
+
+	br, err := b.allow(XidGenerate, XidGenerate, time.Now())
+	if err != nil || br == nil {
+		t.Fatalf("allow() = %v, %v, want a breaker", br, err)
+	}
+
+	br.observe(context.Background(), nil, errors.New("down"), time.Now())
+	br.release()
+
+	if br.failures != 0 || br.state != BreakerClosed {
+		t.Errorf("breaker recorded the call: %+v", br)
+	}
+}
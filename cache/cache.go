@@ -0,0 +1,110 @@
+// Package cache provides the response cache used by the client to avoid
+// resending the same HEM, together with an in-process LRU implementation.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/ceeideu/sdk/xid"
+)
+
+const DefaultSize = 10000
+
+// Backend stores xID responses by key. Implementations must be safe for
+// concurrent use.
+type Backend interface {
+	// Get returns the response stored under key, if present and not expired.
+	Get(key string) (xid.Response, bool)
+	// Set stores the response under key for ttl.
+	Set(key string, value xid.Response, ttl time.Duration)
+}
+
+// LRU is an in-process Backend holding at most size entries, evicting the
+// least recently used one when full.
+type LRU struct {
+	size    int
+	now     func() time.Time
+	entries map[string]*list.Element
+	order   *list.List
+
+	m sync.Mutex
+}
+
+type entry struct {
+	key     string
+	value   xid.Response
+	expires time.Time
+}
+
+// NewLRU returns an LRU holding at most size entries, DefaultSize when size
+// is not positive.
+func NewLRU(size int) *LRU {
+	if size <= 0 {
+		size = DefaultSize
+	}
+
+	return &LRU{
+		size:    size,
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (c *LRU) Get(key string) (xid.Response, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return xid.Response{}, false
+	}
+
+	e, _ := el.Value.(*entry)
+	if !c.now().Before(e.expires) {
+		c.remove(el)
+
+		return xid.Response{}, false
+	}
+
+	c.order.MoveToFront(el)
+
+	return e.value, true
+}
+
+func (c *LRU) Set(key string, value xid.Response, ttl time.Duration) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	expires := c.now().Add(ttl)
+
+	if el, ok := c.entries[key]; ok {
+		e, _ := el.Value.(*entry)
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(el)
+
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&entry{key: key, value: value, expires: expires})
+
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// Len returns the number of entries held, including expired ones not yet evicted.
+func (c *LRU) Len() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *LRU) remove(el *list.Element) {
+	e, _ := el.Value.(*entry)
+	delete(c.entries, e.key)
+	c.order.Remove(el)
+}
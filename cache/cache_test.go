@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ceeideu/sdk/xid"
+)
+
+func TestLRU(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1700000000, 0)
+	c := NewLRU(2)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", xid.Response{Value: "a"}, time.Minute)
+	c.Set("b", xid.Response{Value: "b"}, time.Minute)
+
+	if v, ok := c.Get("a"); !ok || v.Value != "a" {
+		t.Fatalf("Get(a) = %v, %v", v, ok)
+	}
+
+	// b is now the least recently used entry.
+	c.Set("c", xid.Response{Value: "c"}, 2*time.Minute)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("Get(b) found an evicted entry")
+	}
+
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", c.Len())
+	}
+
+	now = now.Add(time.Minute)
+
+	if _, ok := c.Get("a"); ok {
+		t.Error("Get(a) found an expired entry")
+	}
+
+	if v, ok := c.Get("c"); !ok || v.Value != "c" {
+		t.Errorf("Get(c) = %v, %v", v, ok)
+	}
+
+	c.Set("c", xid.Response{Value: "c2"}, time.Minute)
+
+	if v, _ := c.Get("c"); v.Value != "c2" {
+		t.Errorf("Get(c) = %v, want the updated value", v)
+	}
+
+	if c.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", c.Len())
+	}
+}
+
+func TestNewLRU_DefaultSize(t *testing.T) {
+	t.Parallel()
+
+	if c := NewLRU(0); c.size != DefaultSize {
+		t.Errorf("NewLRU(0).size = %d, want %d", c.size, DefaultSize)
+	}
+}
@@ -0,0 +1,19 @@
+package client
+
+import (
+	"context"
+
+	"github.com/ceeideu/sdk/hem"
+	"github.com/ceeideu/sdk/xid"
+)
+
+// Client is the part of XID used by publishers and bidders, to be mocked in
+// tests, e.g. with the fake package.
+type Client interface {
+	Send(ctx context.Context, hemReq hem.Request) (xid.Response, error)
+	RefreshXID(ctx context.Context, refreshReq xid.RefreshReq) (xid.RefreshResp, error)
+	TokenFromXID(_xid string) (xid.Token, error)
+	DecryptToken(token xid.Token) (string, error)
+}
+
+var _ Client = (*XID)(nil)
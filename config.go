@@ -0,0 +1,346 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ceeideu/sdk/cache"
+)
+
+const (
+	EnvironmentProduction = "production"
+	EnvironmentSandbox    = "sandbox"
+
+	EnvBaseURL         = "CEEID_BASE_URL"
+	EnvEndpoints       = "CEEID_ENDPOINTS"
+	EnvEnvironment     = "CEEID_ENVIRONMENT"
+	EnvAPIKey          = "CEEID_API_KEY"
+	EnvAPIKeyFile      = "CEEID_API_KEY_FILE"
+	EnvGenerateTimeout = "CEEID_GENERATE_TIMEOUT"
+	EnvRefreshTimeout  = "CEEID_REFRESH_TIMEOUT"
+	EnvKeysTimeout     = "CEEID_KEYS_TIMEOUT"
+	EnvDefaultTimeout  = "CEEID_DEFAULT_TIMEOUT"
+	EnvRetryAttempts   = "CEEID_RETRY_MAX_ATTEMPTS"
+	EnvRetryBaseDelay  = "CEEID_RETRY_BASE_DELAY"
+	EnvRetryMaxDelay   = "CEEID_RETRY_MAX_DELAY"
+	EnvCacheSize       = "CEEID_CACHE_SIZE"
+	EnvCacheTTL        = "CEEID_CACHE_TTL"
+	EnvRefreshInterval = "CEEID_REFRESH_INTERVAL"
+)
+
+var (
+	ErrConfig     = errors.New("config error")
+	ErrMockAPIKey = errors.New("mock api key outside sandbox")
+)
+
+// Config is the declarative configuration of a client, built with
+// NewXIDFromConfig. Zero values keep the defaults of the client.
+type Config struct {
+	BaseURL string `json:"base_url" yaml:"base_url"`
+	// Endpoints are the fallback endpoints, see WithEndpoints.
+	Endpoints []string `json:"endpoints,omitempty" yaml:"endpoints,omitempty"`
+	// Environment is EnvironmentProduction, the default, or EnvironmentSandbox.
+	// XApiMockValue is only accepted in the sandbox.
+	Environment string `json:"environment,omitempty" yaml:"environment,omitempty"`
+
+	// The API key is given either as is, or by the environment variable or
+	// the file holding it.
+	APIKey     string `json:"api_key,omitempty" yaml:"api_key,omitempty"`
+	APIKeyEnv  string `json:"api_key_env,omitempty" yaml:"api_key_env,omitempty"`
+	APIKeyFile string `json:"api_key_file,omitempty" yaml:"api_key_file,omitempty"`
+
+	Timeouts TimeoutsConfig `json:"timeouts,omitempty" yaml:"timeouts,omitempty"`
+	Retry    RetryConfig    `json:"retry,omitempty" yaml:"retry,omitempty"`
+	Cache    CacheConfig    `json:"cache,omitempty" yaml:"cache,omitempty"`
+
+	// RefreshInterval enables WithAutoRefresh.
+	RefreshInterval Duration `json:"refresh_interval,omitempty" yaml:"refresh_interval,omitempty"`
+}
+
+type TimeoutsConfig struct {
+	Generate Duration `json:"generate,omitempty" yaml:"generate,omitempty"`
+	Refresh  Duration `json:"refresh,omitempty" yaml:"refresh,omitempty"`
+	Keys     Duration `json:"keys,omitempty" yaml:"keys,omitempty"`
+	Default  Duration `json:"default,omitempty" yaml:"default,omitempty"`
+}
+
+type RetryConfig struct {
+	MaxAttempts int      `json:"max_attempts,omitempty" yaml:"max_attempts,omitempty"`
+	BaseDelay   Duration `json:"base_delay,omitempty" yaml:"base_delay,omitempty"`
+	MaxDelay    Duration `json:"max_delay,omitempty" yaml:"max_delay,omitempty"`
+}
+
+// CacheConfig enables WithCache when Size is positive.
+type CacheConfig struct {
+	Size int      `json:"size,omitempty" yaml:"size,omitempty"`
+	TTL  Duration `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+}
+
+// Duration is a time.Duration written as in time.ParseDuration, e.g. "5s".
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(v)
+
+	return nil
+}
+
+// ReadConfigFile reads a JSON config file. Other formats, e.g. YAML, are
+// decoded by unmarshal, such as yaml.Unmarshal of gopkg.in/yaml.v3.
+func ReadConfigFile(path string, unmarshal func([]byte, any) error) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("%w: %w", ErrConfig, err)
+	}
+
+	if unmarshal == nil {
+		if ext := filepath.Ext(path); ext != ".json" {
+			return Config{}, fmt.Errorf("%w: no unmarshaler for %s files", ErrConfig, ext)
+		}
+
+		unmarshal = json.Unmarshal
+	}
+
+	var cfg Config
+	if err := unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("%w: %s: %w", ErrConfig, path, err)
+	}
+
+	return cfg, nil
+}
+
+// FromEnv returns c overridden by the CEEID_* environment variables which are set.
+func (c Config) FromEnv() (Config, error) {
+	var errs []error
+
+	str := func(name string, dst *string) {
+		if v, ok := os.LookupEnv(name); ok {
+			*dst = v
+		}
+	}
+
+	duration := func(name string, dst *Duration) {
+		if v, ok := os.LookupEnv(name); ok {
+			if err := dst.UnmarshalText([]byte(v)); err != nil {
+				errs = append(errs, fmt.Errorf("%w: %s: %w", ErrConfig, name, err))
+			}
+		}
+	}
+
+	integer := func(name string, dst *int) {
+		if v, ok := os.LookupEnv(name); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%w: %s: %w", ErrConfig, name, err))
+
+				return
+			}
+
+			*dst = n
+		}
+	}
+
+	str(EnvBaseURL, &c.BaseURL)
+	str(EnvEnvironment, &c.Environment)
+
+	if v, ok := os.LookupEnv(EnvEndpoints); ok {
+		c.Endpoints = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	}
+
+	key, hasKey := os.LookupEnv(EnvAPIKey)
+	keyFile, hasKeyFile := os.LookupEnv(EnvAPIKeyFile)
+
+	switch {
+	case hasKey && hasKeyFile:
+		errs = append(errs, fmt.Errorf("%w: only one of %s and %s may be set", ErrConfig, EnvAPIKey, EnvAPIKeyFile))
+	case hasKey:
+		c.APIKey, c.APIKeyEnv, c.APIKeyFile = key, "", ""
+	case hasKeyFile:
+		c.APIKey, c.APIKeyEnv, c.APIKeyFile = "", "", keyFile
+	}
+
+	duration(EnvGenerateTimeout, &c.Timeouts.Generate)
+	duration(EnvRefreshTimeout, &c.Timeouts.Refresh)
+	duration(EnvKeysTimeout, &c.Timeouts.Keys)
+	duration(EnvDefaultTimeout, &c.Timeouts.Default)
+	integer(EnvRetryAttempts, &c.Retry.MaxAttempts)
+	duration(EnvRetryBaseDelay, &c.Retry.BaseDelay)
+	duration(EnvRetryMaxDelay, &c.Retry.MaxDelay)
+	integer(EnvCacheSize, &c.Cache.Size)
+	duration(EnvCacheTTL, &c.Cache.TTL)
+	duration(EnvRefreshInterval, &c.RefreshInterval)
+
+	return c, errors.Join(errs...)
+}
+
+// LoadConfig reads the config file at path, if not empty, and overrides it
+// with the environment variables.
+func LoadConfig(path string, unmarshal func([]byte, any) error) (Config, error) {
+	var cfg Config
+
+	if path != "" {
+		var err error
+		if cfg, err = ReadConfigFile(path, unmarshal); err != nil {
+			return Config{}, err
+		}
+	}
+
+	return cfg.FromEnv()
+}
+
+// Validate returns all the problems of the config, joined.
+func (c Config) Validate() error {
+	var errs []error
+
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: "+format, append([]any{ErrConfig}, args...)...))
+	}
+
+	if c.BaseURL == "" {
+		invalid("base_url is required")
+	}
+
+	for _, u := range append([]string{c.BaseURL}, c.Endpoints...) {
+		if u == "" {
+			continue
+		}
+
+		if parsed, err := url.Parse(u); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			invalid("invalid endpoint %q", u)
+		}
+	}
+
+	switch c.Environment {
+	case "", EnvironmentProduction, EnvironmentSandbox:
+	default:
+		invalid("unknown environment %q", c.Environment)
+	}
+
+	sources := 0
+	for _, s := range []string{c.APIKey, c.APIKeyEnv, c.APIKeyFile} {
+		if s != "" {
+			sources++
+		}
+	}
+
+	if sources != 1 {
+		invalid("exactly one of api_key, api_key_env and api_key_file is required")
+	}
+
+	if c.APIKey == XApiMockValue && c.Environment != EnvironmentSandbox {
+		errs = append(errs, fmt.Errorf("%w: %w", ErrConfig, ErrMockAPIKey))
+	}
+
+	for _, d := range []struct {
+		name  string
+		value Duration
+	}{
+		{"timeouts.generate", c.Timeouts.Generate}, {"timeouts.refresh", c.Timeouts.Refresh},
+		{"timeouts.keys", c.Timeouts.Keys}, {"timeouts.default", c.Timeouts.Default},
+		{"retry.base_delay", c.Retry.BaseDelay}, {"retry.max_delay", c.Retry.MaxDelay},
+		{"cache.ttl", c.Cache.TTL}, {"refresh_interval", c.RefreshInterval},
+	} {
+		if d.value < 0 {
+			invalid("%s must not be negative", d.name)
+		}
+	}
+
+	if c.Retry.MaxAttempts < 0 || c.Cache.Size < 0 {
+		invalid("retry.max_attempts and cache.size must not be negative")
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c Config) credentials() CredentialProvider {
+	switch {
+	case c.APIKeyEnv != "":
+		return EnvCredentials(c.APIKeyEnv)
+	case c.APIKeyFile != "":
+		return NewFileCredentials(c.APIKeyFile)
+	default:
+		return StaticCredentials(c.APIKey)
+	}
+}
+
+func (c Config) options() []func(*XID) {
+	timeouts := DefaultTimeouts()
+	for _, t := range []struct {
+		dst *time.Duration
+		v   Duration
+	}{
+		{&timeouts.Generate, c.Timeouts.Generate}, {&timeouts.Refresh, c.Timeouts.Refresh},
+		{&timeouts.Keys, c.Timeouts.Keys}, {&timeouts.Default, c.Timeouts.Default},
+	} {
+		if t.v > 0 {
+			*t.dst = time.Duration(t.v)
+		}
+	}
+
+	opts := []func(*XID){
+		WithCredentials(c.credentials()),
+		WithTimeouts(timeouts),
+		WithEndpoints(c.Endpoints...),
+	}
+
+	if c.Retry != (RetryConfig{}) {
+		retry := DefaultRetryPolicy()
+		if c.Retry.MaxAttempts > 0 {
+			retry.MaxAttempts = c.Retry.MaxAttempts
+		}
+
+		if c.Retry.BaseDelay > 0 {
+			retry.BaseDelay = time.Duration(c.Retry.BaseDelay)
+		}
+
+		if c.Retry.MaxDelay > 0 {
+			retry.MaxDelay = time.Duration(c.Retry.MaxDelay)
+		}
+
+		opts = append(opts, WithRetryPolicy(retry))
+	}
+
+	if c.Cache.Size > 0 {
+		opts = append(opts, WithCache(cache.NewLRU(c.Cache.Size), time.Duration(c.Cache.TTL)))
+	}
+
+	if c.RefreshInterval > 0 {
+		opts = append(opts, WithAutoRefresh(time.Duration(c.RefreshInterval)))
+	}
+
+	return opts
+}
+
+// NewXIDFromConfig validates cfg and returns the client it describes. opts
+// are applied after the ones derived from cfg.
+func NewXIDFromConfig(cfg Config, opts ...func(*XID)) (*XID, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if cfg.Environment != EnvironmentSandbox {
+		// the key given by the environment or a file is checked once
+		if key, err := cfg.credentials().APIKey(context.Background()); err == nil && key == XApiMockValue {
+			return nil, fmt.Errorf("%w: %w", ErrConfig, ErrMockAPIKey)
+		}
+	}
+
+	return NewXID(cfg.BaseURL, "", append(cfg.options(), opts...)...)
+}
@@ -0,0 +1,206 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadConfigFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	data := `{
+		"base_url": "https://ceeid.example",
+		"endpoints": ["https://eu2.ceeid.example"],
+		"api_key_file": "/run/secrets/ceeid",
+		"timeouts": {"generate": "2s"},
+		"retry": {"max_attempts": 3, "base_delay": "50ms"},
+		"cache": {"size": 100, "ttl": "1h"},
+		"refresh_interval": "1s"
+	}`
+	want := Config{
+		BaseURL:         "https://ceeid.example",
+		Endpoints:       []string{"https://eu2.ceeid.example"},
+		APIKeyFile:      "/run/secrets/ceeid",
+		Timeouts:        TimeoutsConfig{Generate: Duration(2 * time.Second)},
+		Retry:           RetryConfig{MaxAttempts: 3, BaseDelay: Duration(50 * time.Millisecond)},
+		Cache:           CacheConfig{Size: 100, TTL: Duration(time.Hour)},
+		RefreshInterval: Duration(time.Second),
+	}
+
+	jsonPath := filepath.Join(dir, "ceeid.json")
+	yamlPath := filepath.Join(dir, "ceeid.yaml")
+	_ = os.WriteFile(jsonPath, []byte(data), 0o600)
+	_ = os.WriteFile(yamlPath, []byte(data), 0o600)
+
+	got, err := ReadConfigFile(jsonPath, nil)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadConfigFile() = %+v, %v, want %+v", got, err, want)
+	}
+
+	if _, err := ReadConfigFile(yamlPath, nil); !errors.Is(err, ErrConfig) {
+		t.Errorf("ReadConfigFile() error = %v, want %v", err, ErrConfig)
+	}
+
+	// JSON is valid YAML, any YAML unmarshaler would decode it the same way
+	got, err = ReadConfigFile(yamlPath, json.Unmarshal)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadConfigFile() = %+v, %v, want %+v", got, err, want)
+	}
+
+	_ = os.WriteFile(jsonPath, []byte(`{"timeouts": {"generate": "soon"}}`), 0o600)
+	if _, err := ReadConfigFile(jsonPath, nil); !errors.Is(err, ErrConfig) {
+		t.Errorf("ReadConfigFile() error = %v, want %v", err, ErrConfig)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ceeid.json")
+	_ = os.WriteFile(path, []byte(`{"base_url": "https://file.example", "api_key_env": "APP_CEEID_KEY", "cache": {"size": 10}}`), 0o600)
+
+	t.Setenv(EnvBaseURL, "https://env.example")
+	t.Setenv(EnvEndpoints, "https://a.example, https://b.example")
+	t.Setenv(EnvAPIKey, "env-key")
+	t.Setenv(EnvGenerateTimeout, "3s")
+	t.Setenv(EnvRetryAttempts, "2")
+
+	got, err := LoadConfig(path, nil)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	want := Config{
+		BaseURL:   "https://env.example",
+		Endpoints: []string{"https://a.example", "https://b.example"},
+		APIKey:    "env-key",
+		Timeouts:  TimeoutsConfig{Generate: Duration(3 * time.Second)},
+		Retry:     RetryConfig{MaxAttempts: 2},
+		Cache:     CacheConfig{Size: 10},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadConfig() = %+v, want %+v", got, want)
+	}
+
+	t.Setenv(EnvCacheTTL, "forever")
+	t.Setenv(EnvCacheSize, "many")
+
+	t.Setenv(EnvAPIKeyFile, "/run/secrets/ceeid")
+
+	got, err = Config{APIKey: "config-key", Cache: CacheConfig{Size: 10}}.FromEnv()
+	for _, name := range []string{EnvCacheTTL, EnvCacheSize, EnvAPIKeyFile} {
+		if !errors.Is(err, ErrConfig) || !strings.Contains(err.Error(), name) {
+			t.Errorf("Config.FromEnv() error = %v, want an error for %s", err, name)
+		}
+	}
+
+	// invalid values leave the config unchanged
+	if got.Cache.Size != 10 || got.APIKey != "config-key" || got.APIKeyFile != "" {
+		t.Errorf("Config.FromEnv() = %+v, want the invalid values ignored", got)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr []string
+	}{
+		{
+			name: "valid",
+			cfg:  Config{BaseURL: "https://ceeid.example", APIKey: "key"},
+		},
+		{
+			name: "sandbox mock",
+			cfg:  Config{BaseURL: "https://ceeid.example", APIKey: XApiMockValue, Environment: EnvironmentSandbox},
+		},
+		{
+			name:    "production mock",
+			cfg:     Config{BaseURL: "https://ceeid.example", APIKey: XApiMockValue},
+			wantErr: []string{ErrMockAPIKey.Error()},
+		},
+		{
+			name:    "empty",
+			cfg:     Config{},
+			wantErr: []string{"base_url is required", "api_key"},
+		},
+		{
+			name: "aggregated",
+			cfg: Config{
+				BaseURL: "ceeid.example", Endpoints: []string{"https://ok.example", "::"},
+				APIKey: "key", APIKeyFile: "/key", Environment: "staging",
+				Timeouts: TimeoutsConfig{Keys: -1}, Retry: RetryConfig{MaxAttempts: -1},
+			},
+			wantErr: []string{
+				`invalid endpoint "ceeid.example"`, `invalid endpoint "::"`, `unknown environment "staging"`,
+				"exactly one of", "timeouts.keys must not be negative", "retry.max_attempts",
+			},
+		},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			err := test.cfg.Validate()
+			if (err != nil) != (len(test.wantErr) > 0) || (err != nil && !errors.Is(err, ErrConfig)) {
+				t.Fatalf("Config.Validate() error = %v, want %v", err, test.wantErr)
+			}
+			for _, want := range test.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Config.Validate() error = %v, want %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewXIDFromConfig(t *testing.T) {
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(XApiKeyHeader)
+	}))
+	defer ts.Close()
+
+	t.Setenv("APP_CEEID_KEY", "env-key")
+
+	xidClient, err := NewXIDFromConfig(Config{
+		BaseURL:   ts.URL,
+		Endpoints: []string{"https://eu2.ceeid.example"},
+		APIKeyEnv: "APP_CEEID_KEY",
+		Timeouts:  TimeoutsConfig{Generate: Duration(time.Second)},
+		Retry:     RetryConfig{MaxAttempts: 5},
+		Cache:     CacheConfig{Size: 10},
+	}, WithHTTPClient(ts.Client()))
+	if err != nil {
+		t.Fatalf("NewXIDFromConfig() error = %v", err)
+	}
+
+	_, _ = xidClient.DoHTTPReq(context.Background(), http.MethodGet, ts.URL, nil)
+
+	if got != "env-key" {
+		t.Errorf("%s = %q, want env-key", XApiKeyHeader, got)
+	}
+
+	wantTimeouts := DefaultTimeouts()
+	wantTimeouts.Generate = time.Second
+
+	if xidClient.timeouts != wantTimeouts || xidClient.retry.MaxAttempts != 5 ||
+		xidClient.cache.backend == nil || len(xidClient.endpoints) != 2 {
+		t.Errorf("NewXIDFromConfig() = %+v", xidClient)
+	}
+
+	t.Setenv("APP_CEEID_KEY", XApiMockValue)
+
+	if _, err := NewXIDFromConfig(Config{BaseURL: ts.URL, APIKeyEnv: "APP_CEEID_KEY"}); !errors.Is(err, ErrMockAPIKey) {
+		t.Errorf("NewXIDFromConfig() error = %v, want %v", err, ErrMockAPIKey)
+	}
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const DefaultCredentialsReloadInterval = time.Second
+
+var (
+	ErrCredentials = errors.New("credentials error")
+	ErrNoAPIKey    = errors.New("no api key")
+)
+
+// CredentialProvider supplies the API key sent with every request.
+// Implementations must be safe for concurrent use.
+type CredentialProvider interface {
+	APIKey(ctx context.Context) (string, error)
+	// Invalidate is called when the service rejects the API key, so that the
+	// next APIKey call fetches it again.
+	Invalidate()
+}
+
+// WithCredentials makes the client ask p for the API key on every request,
+// instead of sending the one passed to NewXID. When the service answers 401
+// or 403, the credentials are invalidated and the request is retried once
+// if the API key changed.
+func WithCredentials(p CredentialProvider) func(*XID) {
+	return func(x *XID) {
+		x.credentials = p
+	}
+}
+
+// StaticCredentials is a fixed API key.
+type StaticCredentials string
+
+func (c StaticCredentials) APIKey(context.Context) (string, error) {
+	return string(c), nil
+}
+
+func (StaticCredentials) Invalidate() {}
+
+// EnvCredentials reads the API key from the environment variable on every request.
+type EnvCredentials string
+
+func (c EnvCredentials) APIKey(context.Context) (string, error) {
+	key := os.Getenv(string(c))
+	if key == "" {
+		return "", fmt.Errorf("%w: %s is not set", ErrNoAPIKey, string(c))
+	}
+
+	return key, nil
+}
+
+func (EnvCredentials) Invalidate() {}
+
+// FileCredentials reads the API key from a file, reloading it when the file
+// is modified. Leading and trailing white space is ignored.
+type FileCredentials struct {
+	path     string
+	interval time.Duration
+	now      func() time.Time
+
+	key     string
+	modTime time.Time
+	checked time.Time
+
+	m sync.Mutex
+}
+
+// NewFileCredentials returns credentials read from path, checked for
+// modifications at most once per DefaultCredentialsReloadInterval.
+func NewFileCredentials(path string) *FileCredentials {
+	return &FileCredentials{path: path, interval: DefaultCredentialsReloadInterval, now: time.Now}
+}
+
+func (c *FileCredentials) APIKey(context.Context) (string, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	now := c.now()
+	if c.key != "" && now.Sub(c.checked) < c.interval {
+		return c.key, nil
+	}
+
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return "", err
+	}
+
+	c.checked = now
+
+	if c.key != "" && info.ModTime().Equal(c.modTime) {
+		return c.key, nil
+	}
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return "", err
+	}
+
+	key := string(bytes.TrimSpace(data))
+	if key == "" {
+		return "", fmt.Errorf("%w: %s is empty", ErrNoAPIKey, c.path)
+	}
+
+	c.key, c.modTime = key, info.ModTime()
+
+	return c.key, nil
+}
+
+func (c *FileCredentials) Invalidate() {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.key = ""
+}
+
+func (x *XID) apiKey(ctx context.Context) (string, error) {
+	if x.credentials == nil {
+		return "", nil
+	}
+
+	key, err := x.credentials.APIKey(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrCredentials, err)
+	}
+
+	return key, nil
+}
+
+// reauthenticate invalidates the credentials after the service rejected the
+// API key sent with req, reporting whether a different key is now available.
+func (x *XID) reauthenticate(ctx context.Context, req *http.Request, resp *http.Response) bool {
+	if x.credentials == nil || resp == nil ||
+		(resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden) {
+		return false
+	}
+
+	x.credentials.Invalidate()
+
+	key, err := x.credentials.APIKey(ctx)
+
+	return err == nil && key != req.Header.Get(XApiKeyHeader)
+}
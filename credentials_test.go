@@ -0,0 +1,158 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ceeideu/sdk/hem"
+	"github.com/ceeideu/sdk/xid"
+)
+
+type rotatingCredentials struct {
+	keys        []string
+	invalidated int
+
+	m sync.Mutex
+}
+
+func (c *rotatingCredentials) APIKey(context.Context) (string, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.keys[min(c.invalidated, len(c.keys)-1)], nil
+}
+
+func (c *rotatingCredentials) Invalidate() {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.invalidated++
+}
+
+func TestWithCredentials(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		keys     []string
+		status   int
+		wantErr  error
+		wantHits int32
+	}{
+		{name: "valid", keys: []string{"valid"}, wantHits: 1},
+		{name: "rotated", keys: []string{"revoked", "valid"}, wantHits: 2},
+		{name: "forbidden rotated", keys: []string{"revoked", "valid"}, status: http.StatusForbidden, wantHits: 2},
+		{name: "unchanged", keys: []string{"revoked"}, wantErr: ErrStatusNotOK, wantHits: 1},
+		{name: "retried once", keys: []string{"revoked", "revoked2", "valid"}, wantErr: ErrStatusNotOK, wantHits: 2},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			var hits atomic.Int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hits.Add(1)
+				if r.Header.Get(XApiKeyHeader) != "valid" {
+					w.WriteHeader(max(test.status, http.StatusUnauthorized))
+
+					return
+				}
+				m, _ := json.Marshal(xid.Response{Value: "xid", Status: xid.Okay})
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write(m)
+			}))
+			defer ts.Close()
+
+			xidClient, _ := NewXID(ts.URL, XApiMockValue, WithHTTPClient(ts.Client()),
+				WithCredentials(&rotatingCredentials{keys: test.keys}))
+
+			_, err := xidClient.Send(context.Background(), hem.FromEmail("test@com"))
+			if !errors.Is(err, test.wantErr) || (err == nil) != (test.wantErr == nil) {
+				t.Errorf("XID.Send() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if h := hits.Load(); h != test.wantHits {
+				t.Errorf("server hits = %d, want %d", h, test.wantHits)
+			}
+		})
+	}
+}
+
+func TestNewXID_StaticCredentials(t *testing.T) {
+	t.Parallel()
+
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(XApiKeyHeader)
+	}))
+	defer ts.Close()
+
+	xidClient, _ := NewXID(ts.URL, "static-key", WithHTTPClient(ts.Client()))
+	_, _ = xidClient.DoHTTPReq(context.Background(), http.MethodGet, ts.URL, nil)
+
+	if got != "static-key" {
+		t.Errorf("%s = %q, want %q", XApiKeyHeader, got, "static-key")
+	}
+}
+
+func TestEnvCredentials(t *testing.T) {
+	t.Setenv("CEEID_TEST_API_KEY", "env-key")
+
+	if key, err := EnvCredentials("CEEID_TEST_API_KEY").APIKey(context.Background()); err != nil || key != "env-key" {
+		t.Errorf("EnvCredentials.APIKey() = %v, %v, want env-key", key, err)
+	}
+
+	if _, err := EnvCredentials("CEEID_TEST_API_KEY_UNSET").APIKey(context.Background()); !errors.Is(err, ErrNoAPIKey) {
+		t.Errorf("EnvCredentials.APIKey() error = %v, want %v", err, ErrNoAPIKey)
+	}
+}
+
+func TestFileCredentials(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "api-key")
+	_ = os.WriteFile(path, []byte("first\n"), 0o600)
+
+	now := time.Unix(1700000000, 0)
+	c := NewFileCredentials(path)
+	c.now = func() time.Time { return now }
+
+	if key, err := c.APIKey(context.Background()); err != nil || key != "first" {
+		t.Fatalf("FileCredentials.APIKey() = %v, %v, want first", key, err)
+	}
+
+	_ = os.WriteFile(path, []byte("second"), 0o600)
+	_ = os.Chtimes(path, now, now.Add(time.Second))
+
+	if key, _ := c.APIKey(context.Background()); key != "first" {
+		t.Errorf("FileCredentials.APIKey() = %v before the reload interval, want first", key)
+	}
+
+	now = now.Add(DefaultCredentialsReloadInterval)
+
+	if key, _ := c.APIKey(context.Background()); key != "second" {
+		t.Errorf("FileCredentials.APIKey() = %v after modification, want second", key)
+	}
+
+	_ = os.WriteFile(path, []byte("third"), 0o600)
+	_ = os.Chtimes(path, now, now.Add(2*time.Second))
+	c.Invalidate()
+
+	if key, _ := c.APIKey(context.Background()); key != "third" {
+		t.Errorf("FileCredentials.APIKey() = %v after Invalidate, want third", key)
+	}
+
+	_ = os.WriteFile(path, nil, 0o600)
+	c.Invalidate()
+
+	if _, err := c.APIKey(context.Background()); !errors.Is(err, ErrNoAPIKey) {
+		t.Errorf("FileCredentials.APIKey() error = %v, want %v", err, ErrNoAPIKey)
+	}
+}
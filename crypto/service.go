@@ -8,6 +8,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"time"
+
+	"github.com/ceeideu/sdk/metrics"
 )
 
 var (
@@ -19,9 +22,15 @@ var (
 	ErrBlock     = errors.New("block error")
 )
 
+const (
+	opEncrypt = "encrypt"
+	opDecrypt = "decrypt"
+)
+
 type Service struct {
 	cipher  Cipher
 	keyRepo KeyRepository
+	metrics metrics.Metrics
 }
 
 type Cipher interface {
@@ -29,10 +38,22 @@ type Cipher interface {
 	Decrypt(aead cipher.AEAD, _bytes []byte) ([]byte, error)
 }
 
-func NewService() *Service {
-	return &Service{
+func NewService(opts ...func(*Service)) *Service {
+	s := &Service{
 		cipher: &GCMCipher{reader: rand.Reader},
 	}
+
+	for _, o := range opts {
+		o(s)
+	}
+
+	return s
+}
+
+func WithMetrics(m metrics.Metrics) func(*Service) {
+	return func(s *Service) {
+		s.metrics = m
+	}
 }
 
 type EncResp struct {
@@ -41,6 +62,13 @@ type EncResp struct {
 }
 
 func (s *Service) Encrypt(data []byte) (EncResp, error) {
+	resp, err := s.encrypt(data)
+	s.observe(opEncrypt, err)
+
+	return resp, err
+}
+
+func (s *Service) encrypt(data []byte) (EncResp, error) {
 	encryptionKey, err := s.keyRepo.EncryptionKey()
 	if err != nil {
 		return EncResp{}, err
@@ -57,6 +85,13 @@ func (s *Service) Encrypt(data []byte) (EncResp, error) {
 }
 
 func (s *Service) Decrypt(keyID uint8, data []byte) ([]byte, error) {
+	decrypted, err := s.decrypt(keyID, data)
+	s.observe(opDecrypt, err)
+
+	return decrypted, err
+}
+
+func (s *Service) decrypt(keyID uint8, data []byte) ([]byte, error) {
 	key, err := s.keyRepo.DecryptionKey(keyID)
 	if err != nil {
 		return nil, err
@@ -105,9 +140,38 @@ func (s *Service) KeysRefresh(keys Keys) error {
 	}
 	s.keyRepo.Set(repo)
 
+	m := metrics.OrNoop(s.metrics)
+	m.SetGauge(metrics.KeysUpdated, float64(time.Now().Unix()))
+	m.SetGauge(metrics.DecryptionKeys, float64(len(repo.Decryption)))
+
 	return nil
 }
 
+func (s *Service) observe(operation string, err error) {
+	metrics.OrNoop(s.metrics).IncCounter(metrics.CryptoOperationsTotal,
+		metrics.Label{Name: metrics.LabelOperation, Value: operation},
+		metrics.Label{Name: metrics.LabelResult, Value: Result(err)},
+	)
+}
+
+// Result classifies the outcome of a crypto operation for metrics.
+func Result(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, ErrKeyNotPresent):
+		return "key_not_present"
+	case errors.Is(err, ErrNilEncKey):
+		return "no_encryption_key"
+	case errors.Is(err, ErrOpen), errors.Is(err, ErrNonceSize):
+		return "decrypt"
+	case errors.Is(err, ErrRead):
+		return "read"
+	default:
+		return "error"
+	}
+}
+
 func ParseKey(value string) (cipher.AEAD, error) {
 	_bytes, err := hex.DecodeString(value)
 	if err != nil {
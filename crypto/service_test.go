@@ -3,6 +3,7 @@ package crypto
 import (
 	"crypto/cipher"
 	"encoding/hex"
+	"fmt"
 	"io"
 	"reflect"
 	"sync"
@@ -422,3 +423,27 @@ func TestService_TokenEncryptDecrypt(t *testing.T) {
 		t.Errorf("err")
 	}
 }
+
+func TestResult(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "ok", err: nil, want: "ok"},
+		{name: "key not present", err: ErrKeyNotPresent, want: "key_not_present"},
+		{name: "nil enc key", err: ErrNilEncKey, want: "no_encryption_key"},
+		{name: "open", err: fmt.Errorf("%s: %w", "decrypt error", ErrOpen), want: "decrypt"},
+		{name: "other", err: ErrCipher, want: "error"},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if got := Result(test.err); got != test.want {
+				t.Errorf("Result() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package crypto
+
+import (
+	"crypto/cipher"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var (
+	ErrNoSnapshot = errors.New("no key snapshot")
+	ErrSnapshot   = errors.New("key snapshot error")
+)
+
+// SnapshotStore persists the last good keys, so they can be used right after
+// a restart, before the first refresh.
+type SnapshotStore interface {
+	// Load returns the stored keys, or ErrNoSnapshot when none were saved.
+	Load() (Keys, error)
+	Save(keys Keys) error
+}
+
+// FileSnapshotStore stores the keys in a file encrypted with a master key.
+// The file is replaced atomically on each save.
+type FileSnapshotStore struct {
+	path      string
+	masterKey cipher.AEAD
+	cipher    Cipher
+}
+
+// NewFileSnapshotStore returns a store writing to path, encrypting with the
+// hex encoded 128, 192 or 256 bit AES masterKey.
+func NewFileSnapshotStore(path, masterKey string) (*FileSnapshotStore, error) {
+	key, err := ParseKey(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileSnapshotStore{path: path, masterKey: key, cipher: NewGCMCipher()}, nil
+}
+
+func (s *FileSnapshotStore) Load() (Keys, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Keys{}, ErrNoSnapshot
+	}
+
+	if err != nil {
+		return Keys{}, fmt.Errorf("%w: %w", ErrSnapshot, err)
+	}
+
+	plain, err := s.cipher.Decrypt(s.masterKey, data)
+	if err != nil {
+		return Keys{}, fmt.Errorf("%w: %w", ErrSnapshot, err)
+	}
+
+	var keys Keys
+	if err := json.Unmarshal(plain, &keys); err != nil {
+		return Keys{}, fmt.Errorf("%w: %w", ErrSnapshot, err)
+	}
+
+	return keys, nil
+}
+
+func (s *FileSnapshotStore) Save(keys Keys) error {
+	plain, err := json.Marshal(keys)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSnapshot, err)
+	}
+
+	data, err := s.cipher.Encrypt(s.masterKey, plain)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSnapshot, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSnapshot, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("%w: %w", ErrSnapshot, err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("%w: %w", ErrSnapshot, err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("%w: %w", ErrSnapshot, err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("%w: %w", ErrSnapshot, err)
+	}
+
+	return nil
+}
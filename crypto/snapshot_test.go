@@ -0,0 +1,58 @@
+package crypto
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFileSnapshotStore(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "keys")
+	masterKey := strings.Repeat("ab", 32)
+
+	store, err := NewFileSnapshotStore(path, masterKey)
+	if err != nil {
+		t.Fatalf("NewFileSnapshotStore() error = %v", err)
+	}
+
+	if _, err := store.Load(); !errors.Is(err, ErrNoSnapshot) {
+		t.Errorf("Load() error = %v, want %v", err, ErrNoSnapshot)
+	}
+
+	keys := Keys{
+		Decryption: map[uint8]string{1: cipherKey, 2: cipherKey},
+		Encryption: Encryption{ID: 2, Value: cipherKey},
+	}
+
+	if err := store.Save(keys); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), cipherKey) {
+		t.Error("snapshot holds the keys in clear")
+	}
+
+	got, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(got, keys) {
+		t.Errorf("Load() = %v, want %v", got, keys)
+	}
+
+	other, _ := NewFileSnapshotStore(path, strings.Repeat("cd", 32))
+	if _, err := other.Load(); !errors.Is(err, ErrSnapshot) {
+		t.Errorf("Load() with another master key error = %v, want %v", err, ErrSnapshot)
+	}
+
+	if _, err := NewFileSnapshotStore(path, "zz"); err == nil {
+		t.Error("NewFileSnapshotStore() with an invalid master key succeeded")
+	}
+}
@@ -0,0 +1,336 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ceeideu/sdk/metrics"
+)
+
+const (
+	defaultFailoverThreshold = 3
+	defaultProbeInterval     = 10 * time.Second
+)
+
+// FailoverConfig configures the health tracking of the endpoints.
+type FailoverConfig struct {
+	// FailureThreshold is the number of consecutive failures marking an endpoint unhealthy.
+	// Transport errors and 5xx responses count as failures.
+	FailureThreshold int
+	// ProbeInterval is how often the loop run by Start probes every endpoint.
+	ProbeInterval time.Duration
+	// ProbePath is the path requested by probes, KeysRefresh by default. Any
+	// answer other than 5xx marks the endpoint healthy.
+	ProbePath string
+}
+
+func DefaultFailoverConfig() FailoverConfig {
+	return FailoverConfig{
+		FailureThreshold: defaultFailoverThreshold,
+		ProbeInterval:    defaultProbeInterval,
+		ProbePath:        KeysRefresh,
+	}
+}
+
+// WithEndpoints adds fallback endpoints, preferred in the given order after
+// the base URL passed to NewXID. Calls are routed to the healthiest endpoint
+// and retries go to an endpoint not tried yet by the call.
+func WithEndpoints(baseURLs ...string) func(*XID) {
+	return func(x *XID) {
+		x.fallbackURLs = append(x.fallbackURLs, baseURLs...)
+	}
+}
+
+func WithFailover(cfg FailoverConfig) func(*XID) {
+	return func(x *XID) {
+		x.failover = cfg
+	}
+}
+
+// EndpointStatus is the health of an endpoint.
+type EndpointStatus struct {
+	BaseURL string
+	Healthy bool
+	// Failures is the number of consecutive failures.
+	Failures int
+}
+
+// Endpoints returns the health of the endpoints, in order of preference.
+func (x *XID) Endpoints() []EndpointStatus {
+	statuses := make([]EndpointStatus, 0, len(x.endpoints))
+	for _, ep := range x.endpoints {
+		statuses = append(statuses, ep.status())
+	}
+
+	return statuses
+}
+
+// CallInfo tells how a call was served.
+type CallInfo struct {
+	// BaseURL is the endpoint which served the last attempt.
+	BaseURL  string
+	Attempts int
+}
+
+type callInfoKey struct{}
+
+// callRecorder fills a CallInfo, which may be shared by the concurrent
+// requests of batch calls.
+type callRecorder struct {
+	info *CallInfo
+
+	m sync.Mutex
+}
+
+// WithCallInfo makes the calls made with ctx fill info. It can be read once
+// the call returned.
+func WithCallInfo(ctx context.Context, info *CallInfo) context.Context {
+	return context.WithValue(ctx, callInfoKey{}, &callRecorder{info: info})
+}
+
+func recordCall(ctx context.Context, ep *endpoint, attempt int) {
+	rec, _ := ctx.Value(callInfoKey{}).(*callRecorder)
+	if rec == nil {
+		return
+	}
+
+	rec.m.Lock()
+	defer rec.m.Unlock()
+
+	rec.info.BaseURL = ep.base
+	rec.info.Attempts = attempt + 1
+}
+
+type endpoint struct {
+	url       *url.URL
+	base      string
+	primary   bool
+	threshold int
+
+	failures int
+
+	m sync.Mutex
+}
+
+func newEndpoint(u *url.URL, primary bool, threshold int) *endpoint {
+	return &endpoint{url: u, base: u.String(), primary: primary, threshold: max(threshold, 1)}
+}
+
+func (e *endpoint) status() EndpointStatus {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	return EndpointStatus{BaseURL: e.base, Healthy: e.failures < e.threshold, Failures: e.failures}
+}
+
+// record updates the health of the endpoint, reporting whether it changed.
+func (e *endpoint) record(success bool) bool {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	wasHealthy := e.failures < e.threshold
+
+	if success {
+		e.failures = 0
+	} else {
+		e.failures++
+	}
+
+	return wasHealthy != (e.failures < e.threshold)
+}
+
+// path returns the path of u relative to the endpoint, and whether u is an
+// URL of the endpoint.
+func (e *endpoint) path(u *url.URL) (string, bool) {
+	if u.Scheme != e.url.Scheme || u.Host != e.url.Host {
+		return "", false
+	}
+
+	path, ok := strings.CutPrefix(u.Path, strings.TrimSuffix(e.url.Path, "/"))
+	if !ok || (path != "" && !strings.HasPrefix(path, "/")) {
+		return "", false
+	}
+
+	return path, true
+}
+
+// breakerKey keys the circuit breakers of the endpoint path. Breakers of the
+// primary endpoint are keyed by the path alone.
+func (e *endpoint) breakerKey(path string) string {
+	if e.primary {
+		return path
+	}
+
+	return e.base + path
+}
+
+func (x *XID) newEndpoints(primary *url.URL) error {
+	threshold := x.failover.FailureThreshold
+	if threshold <= 0 {
+		threshold = defaultFailoverThreshold
+	}
+
+	x.endpoints = []*endpoint{newEndpoint(primary, true, threshold)}
+
+	for _, raw := range x.fallbackURLs {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrParse, err)
+		}
+
+		x.endpoints = append(x.endpoints, newEndpoint(u, false, threshold))
+	}
+
+	return nil
+}
+
+// resolve returns the endpoint u belongs to, if any, and the path relative to it.
+func (x *XID) resolve(u *url.URL) (*endpoint, string) {
+	for _, ep := range x.endpoints {
+		if path, ok := ep.path(u); ok {
+			return ep, path
+		}
+	}
+
+	if x.baseURL == nil {
+		return nil, u.Path
+	}
+
+	return nil, strings.TrimPrefix(u.Path, strings.TrimSuffix(x.baseURL.Path, "/"))
+}
+
+// rewrite returns u, an URL of another endpoint, moved to e.
+func (e *endpoint) rewrite(u *url.URL, path string) string {
+	r := *u
+	r.Scheme, r.Host, r.User = e.url.Scheme, e.url.Host, e.url.User
+	r.Path = strings.TrimSuffix(e.url.Path, "/") + path
+	r.RawPath = ""
+
+	return r.String()
+}
+
+// route picks the endpoint serving an attempt of a call to path: the
+// healthiest endpoint, preferring the ones not tried yet by the call, whose
+// circuit is not open. Calls to URLs of no endpoint are not routed.
+func (x *XID) route(routed bool, path string, tried map[*endpoint]bool) (*endpoint, *breaker, error) {
+	if !routed {
+		br, err := x.breakers.allow(path, path, x.clock())
+
+		return nil, br, err
+	}
+
+	candidates := make([]*endpoint, len(x.endpoints))
+	copy(candidates, x.endpoints)
+
+	// healthy endpoints keep their order of preference, unhealthy ones are
+	// ranked by their number of consecutive failures.
+	rank := func(ep *endpoint) (bool, int) {
+		s := ep.status()
+		if s.Healthy {
+			return tried[ep], 0
+		}
+
+		return tried[ep], s.Failures
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		triedI, failuresI := rank(candidates[i])
+		triedJ, failuresJ := rank(candidates[j])
+
+		if triedI != triedJ {
+			return !triedI
+		}
+
+		return failuresI < failuresJ
+	})
+
+	var firstErr error
+
+	for _, ep := range candidates {
+		br, err := x.breakers.allow(ep.breakerKey(path), path, x.clock())
+		if err == nil {
+			return ep, br, nil
+		}
+
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return nil, nil, firstErr
+}
+
+// observeEndpoint records the outcome of an attempt on the health of ep.
+// Attempts canceled by the caller are not held against the endpoint, attempts
+// that ran out of time are.
+func (x *XID) observeEndpoint(ctx context.Context, ep *endpoint, resp *http.Response, err error) {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return
+	}
+
+	x.recordHealth(ctx, ep, err == nil && resp.StatusCode < http.StatusInternalServerError)
+}
+
+func (x *XID) recordHealth(ctx context.Context, ep *endpoint, success bool) {
+	if !ep.record(success) {
+		return
+	}
+
+	healthy, level := 0.0, slog.LevelWarn
+	if success {
+		healthy, level = 1, slog.LevelInfo
+	}
+
+	x.meter().SetGauge(metrics.EndpointHealthy, healthy, metrics.Label{Name: metrics.LabelEndpoint, Value: ep.base})
+	x.log().LogAttrs(ctx, level, "ceeid endpoint health changed",
+		slog.String(LogBaseURL, ep.base), slog.Bool(LogHealthy, success))
+}
+
+func (x *XID) probeLoop(ctx context.Context) {
+	interval := x.failover.ProbeInterval
+	if interval <= 0 {
+		interval = defaultProbeInterval
+	}
+
+	for {
+		if sleep(ctx, jitter(interval, refreshJitter)) != nil {
+			return
+		}
+
+		for _, ep := range x.endpoints {
+			x.probe(ctx, ep)
+		}
+	}
+}
+
+func (x *XID) probe(ctx context.Context, ep *endpoint) {
+	path := x.failover.ProbePath
+	if path == "" {
+		path = KeysRefresh
+	}
+
+	ctx, cancel := x.withTimeout(WithOperation(ctx, OpProbe))
+	defer cancel()
+
+	req, err := x.newRequest(ctx, http.MethodGet, ep.base+path, nil)
+	if err != nil {
+		return
+	}
+
+	resp, err := x.do(req)
+	if ctx.Err() != nil && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return
+	}
+
+	drain(resp)
+
+	x.recordHealth(ctx, ep, err == nil && resp.StatusCode < http.StatusInternalServerError)
+}
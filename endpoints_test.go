@@ -0,0 +1,237 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ceeideu/sdk/hem"
+	"github.com/ceeideu/sdk/xid"
+)
+
+func failoverServer(t *testing.T, prefix string, up *atomic.Bool, hits *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+
+		if !up.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		switch r.URL.Path {
+		case prefix + XidGenerate:
+			m, _ := json.Marshal(xid.Response{Value: "xid", Status: xid.Okay})
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(m)
+		case prefix + KeysRefresh:
+			m, _ := json.Marshal(KeysResp{Encryption: Encryption{ID: 1, Value: testKey}})
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(m)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestWithEndpoints(t *testing.T) {
+	t.Parallel()
+
+	var primaryUp, secondaryUp atomic.Bool
+	var primaryHits, secondaryHits atomic.Int32
+	secondaryUp.Store(true)
+
+	primary := failoverServer(t, "", &primaryUp, &primaryHits)
+	defer primary.Close()
+
+	secondary := failoverServer(t, "/eu", &secondaryUp, &secondaryHits)
+	defer secondary.Close()
+
+	cfg := DefaultFailoverConfig()
+	cfg.FailureThreshold = 2
+	cfg.ProbeInterval = 5 * time.Millisecond
+
+	xidClient, err := NewXID(primary.URL, XApiMockValue, WithEndpoints(secondary.URL+"/eu"), WithFailover(cfg),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2}))
+	if err != nil {
+		t.Fatalf("NewXID() error = %v", err)
+	}
+
+	// non-idempotent calls are not retried, the primary is given up after the threshold
+	for i := 0; i < 2; i++ {
+		if _, err := xidClient.Send(context.Background(), hem.FromEmail("test@com")); err == nil {
+			t.Fatal("XID.Send() succeeded on the failing primary")
+		}
+	}
+
+	if eps := xidClient.Endpoints(); len(eps) != 2 || eps[0].Healthy || !eps[1].Healthy {
+		t.Fatalf("XID.Endpoints() = %v", eps)
+	}
+
+	var info CallInfo
+	if _, err := xidClient.Send(WithCallInfo(context.Background(), &info), hem.FromEmail("test@com")); err != nil {
+		t.Fatalf("XID.Send() error = %v", err)
+	}
+
+	if info.BaseURL != secondary.URL+"/eu" || info.Attempts != 1 {
+		t.Errorf("CallInfo = %+v, want the secondary", info)
+	}
+
+	// the primary recovers and is found healthy by the probes
+	primaryUp.Store(true)
+	_ = xidClient.Start(context.Background())
+	defer xidClient.Close()
+
+	waitFor(t, func() bool { return xidClient.Endpoints()[0].Healthy })
+
+	info = CallInfo{}
+	if _, err := xidClient.Send(WithCallInfo(context.Background(), &info), hem.FromEmail("test@com")); err != nil {
+		t.Fatalf("XID.Send() error = %v", err)
+	}
+
+	if info.BaseURL != primary.URL {
+		t.Errorf("CallInfo = %+v, want the primary", info)
+	}
+
+	// retries go to another endpoint
+	primaryUp.Store(false)
+	_ = xidClient.Close()
+
+	before := secondaryHits.Load()
+	info = CallInfo{}
+	if _, err := xidClient.GetKeys(WithCallInfo(context.Background(), &info)); err != nil {
+		t.Fatalf("XID.GetKeys() error = %v", err)
+	}
+
+	if info.BaseURL != secondary.URL+"/eu" || info.Attempts != 2 || secondaryHits.Load() != before+1 {
+		t.Errorf("CallInfo = %+v, want the secondary on the second attempt", info)
+	}
+}
+
+func TestWithEndpoints_CircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	var primaryUp, secondaryUp atomic.Bool
+	var primaryHits, secondaryHits atomic.Int32
+	secondaryUp.Store(true)
+
+	primary := failoverServer(t, "", &primaryUp, &primaryHits)
+	defer primary.Close()
+
+	secondary := failoverServer(t, "", &secondaryUp, &secondaryHits)
+	defer secondary.Close()
+
+	breakerCfg := DefaultCircuitBreakerConfig()
+	breakerCfg.FailureThreshold = 1
+
+	failoverCfg := DefaultFailoverConfig()
+	failoverCfg.FailureThreshold = 10
+
+	xidClient, _ := NewXID(primary.URL, XApiMockValue, WithEndpoints(secondary.URL),
+		WithCircuitBreaker(breakerCfg), WithFailover(failoverCfg))
+
+	_, _ = xidClient.Send(context.Background(), hem.FromEmail("test@com"))
+
+	if s := xidClient.CircuitState(XidGenerate); s != BreakerOpen {
+		t.Fatalf("XID.CircuitState() = %v, want %v", s, BreakerOpen)
+	}
+
+	// the primary is still healthy, but its circuit is open
+	if _, err := xidClient.Send(context.Background(), hem.FromEmail("test@com")); err != nil {
+		t.Fatalf("XID.Send() error = %v", err)
+	}
+
+	if s := xidClient.CircuitState(secondary.URL + XidGenerate); s != BreakerClosed {
+		t.Errorf("XID.CircuitState() of the secondary = %v, want %v", s, BreakerClosed)
+	}
+
+	if primaryHits.Load() != 1 || secondaryHits.Load() != 1 {
+		t.Errorf("hits = %d, %d, want 1, 1", primaryHits.Load(), secondaryHits.Load())
+	}
+}
+
+func TestWithEndpoints_Timeout(t *testing.T) {
+	t.Parallel()
+
+	hang := make(chan struct{})
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-hang
+	}))
+	defer primary.Close()
+	defer close(hang)
+
+	var secondaryUp atomic.Bool
+	var secondaryHits atomic.Int32
+	secondaryUp.Store(true)
+
+	secondary := failoverServer(t, "", &secondaryUp, &secondaryHits)
+	defer secondary.Close()
+
+	cfg := DefaultFailoverConfig()
+	cfg.FailureThreshold = 2
+
+	timeouts := DefaultTimeouts()
+	timeouts.Generate = 20 * time.Millisecond
+
+	xidClient, _ := NewXID(primary.URL, XApiMockValue, WithEndpoints(secondary.URL), WithFailover(cfg), WithTimeouts(timeouts))
+
+	// the hanging primary is given up after the threshold, without probes
+	for i := 0; i < 2; i++ {
+		if _, err := xidClient.Send(context.Background(), hem.FromEmail("test@com")); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("XID.Send() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+	}
+
+	if eps := xidClient.Endpoints(); eps[0].Healthy {
+		t.Fatalf("XID.Endpoints() = %v, want the primary unhealthy", eps)
+	}
+
+	var info CallInfo
+	if _, err := xidClient.Send(WithCallInfo(context.Background(), &info), hem.FromEmail("test@com")); err != nil {
+		t.Fatalf("XID.Send() error = %v", err)
+	}
+
+	if info.BaseURL != secondary.URL {
+		t.Errorf("CallInfo = %+v, want the secondary", info)
+	}
+}
+
+func Test_endpoint_path(t *testing.T) {
+	t.Parallel()
+
+	base, _ := url.Parse("https://eu.example/v1/")
+	ep := newEndpoint(base, false, 1)
+
+	tests := []struct {
+		name   string
+		url    string
+		want   string
+		wantOK bool
+	}{
+		{name: "path", url: "https://eu.example/v1" + XidGenerate, want: XidGenerate, wantOK: true},
+		{name: "base", url: "https://eu.example/v1", want: "", wantOK: true},
+		{name: "longer segment", url: "https://eu.example/v10" + XidGenerate},
+		{name: "other scheme", url: "http://eu.example/v1" + XidGenerate},
+		{name: "other host", url: "https://us.example/v1" + XidGenerate},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			u, _ := url.Parse(test.url)
+			got, ok := ep.path(u)
+			if got != test.want || ok != test.wantOK {
+				t.Errorf("endpoint.path() = %q, %v, want %q, %v", got, ok, test.want, test.wantOK)
+			}
+		})
+	}
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"log"
-	"net/http"
 	"sync"
 	"time"
 
@@ -36,10 +35,11 @@ func main() {
 func Publisher(tokenChan chan xid.Token, name string, request hem.Request) {
 	// publisher sending hem values
 	// _client represents publisher client sending hem values
-	_client, err := client.NewXID(
-		"[CEEID_ADDRESS]",
-		client.XApiMockValue,
-		client.WithHTTPClient(http.DefaultClient),
+	_client, err := client.NewXIDFromConfig(
+		config(),
+		client.WithRefreshErrorHandler(func(err error) {
+			log.Printf("%s: refresh error: %s", name, err)
+		}),
 	)
 	if err != nil {
 		exit(err)
@@ -47,12 +47,6 @@ func Publisher(tokenChan chan xid.Token, name string, request hem.Request) {
 
 	refresh(_client)
 
-	go func() {
-		for range time.Tick(time.Second) {
-			refresh(_client)
-		}
-	}()
-
 	go func() {
 		for {
 			resp, err := _client.Send(context.Background(), request.WithProperties(
@@ -76,10 +70,11 @@ func Publisher(tokenChan chan xid.Token, name string, request hem.Request) {
 }
 
 func DSP(tokenChan <-chan xid.Token, name string) {
-	_client, err := client.NewXID(
-		"[CEEID_ADDRESS]",
-		client.XApiMockValue,
-		client.WithHTTPClient(http.DefaultClient),
+	_client, err := client.NewXIDFromConfig(
+		config(),
+		client.WithRefreshErrorHandler(func(err error) {
+			log.Printf("%s: refresh error: %s", name, err)
+		}),
 	)
 	if err != nil {
 		exit(err)
@@ -87,12 +82,6 @@ func DSP(tokenChan <-chan xid.Token, name string) {
 
 	refresh(_client)
 
-	go func() {
-		for range time.Tick(time.Second) {
-			refresh(_client)
-		}
-	}()
-
 	go func() {
 		// this routine represents DSP decrypting xid tokens
 		for tkn := range tokenChan {
@@ -119,6 +108,22 @@ func DSP(tokenChan <-chan xid.Token, name string) {
 	}()
 }
 
+// config returns the sandbox configuration, overridden by the CEEID_*
+// environment variables, e.g. CEEID_BASE_URL.
+func config() client.Config {
+	cfg, err := client.Config{
+		BaseURL:         "[CEEID_ADDRESS]",
+		Environment:     client.EnvironmentSandbox,
+		APIKey:          client.XApiMockValue,
+		RefreshInterval: client.Duration(time.Second),
+	}.FromEnv()
+	if err != nil {
+		exit(err)
+	}
+
+	return cfg
+}
+
 func exit(e error) {
 	log.Fatal(e)
 }
@@ -0,0 +1,238 @@
+// Package fake provides an in-memory client.Client for the tests of SDK
+// consumers. It makes no network calls and is deterministic: unless scripted
+// otherwise, Send returns an xID derived from the HEM, RefreshXID returns the
+// xID it is given, and tokens are reversible without keys.
+package fake
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	client "github.com/ceeideu/sdk"
+	"github.com/ceeideu/sdk/hem"
+	"github.com/ceeideu/sdk/xid"
+)
+
+const (
+	MethodSend         = "Send"
+	MethodRefreshXID   = "RefreshXID"
+	MethodTokenFromXID = "TokenFromXID"
+	MethodDecryptToken = "DecryptToken"
+
+	// KeyID is the key ID of the tokens made by TokenFromXID.
+	KeyID = 1
+)
+
+// Call is a recorded call. Arg is the request, xID or token passed.
+type Call struct {
+	Method string
+	Arg    any
+}
+
+type result[T any] struct {
+	value T
+	err   error
+}
+
+// Client is a client.Client. The zero value is ready to use.
+type Client struct {
+	send    map[string]result[xid.Response]
+	refresh map[string]result[xid.RefreshResp]
+	errs    map[string]error
+	calls   []Call
+
+	m sync.Mutex
+}
+
+var _ client.Client = (*Client)(nil)
+
+func New() *Client {
+	return &Client{}
+}
+
+// OnSend scripts the response of Send for the HEM of req. When err is nil
+// and the status is user blocked or invalid consent, Send returns an error
+// wrapping client.ErrUserBlocked or client.ErrConsent, as the client does.
+func (c *Client) OnSend(req hem.Request, resp xid.Response, err error) *Client {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.send == nil {
+		c.send = map[string]result[xid.Response]{}
+	}
+
+	c.send[hemKey(req)] = result[xid.Response]{value: resp, err: orStatusError(err, resp.StatusOf())}
+
+	return c
+}
+
+// OnRefreshXID scripts the response of RefreshXID for the xID, see OnSend.
+func (c *Client) OnRefreshXID(_xid string, resp xid.RefreshResp, err error) *Client {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.refresh == nil {
+		c.refresh = map[string]result[xid.RefreshResp]{}
+	}
+
+	c.refresh[_xid] = result[xid.RefreshResp]{value: resp, err: orStatusError(err, resp.StatusOf())}
+
+	return c
+}
+
+// Fail makes every call of the method return err, or succeed again when err is nil.
+func (c *Client) Fail(method string, err error) *Client {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.errs == nil {
+		c.errs = map[string]error{}
+	}
+
+	c.errs[method] = err
+
+	return c
+}
+
+// Calls returns the calls made so far, in order.
+func (c *Client) Calls() []Call {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return append([]Call(nil), c.calls...)
+}
+
+// CallsOf returns the calls of the method made so far, in order.
+func (c *Client) CallsOf(method string) []Call {
+	var calls []Call
+
+	for _, call := range c.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+// Reset forgets the scripted responses, errors and recorded calls.
+func (c *Client) Reset() {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.send, c.refresh, c.errs, c.calls = nil, nil, nil, nil
+}
+
+// XID returns the xID Send derives from req when not scripted.
+func XID(req hem.Request) string {
+	sum := sha256.Sum256([]byte(hemKey(req)))
+
+	return "fake-" + hex.EncodeToString(sum[:8])
+}
+
+func (c *Client) Send(ctx context.Context, req hem.Request) (xid.Response, error) {
+	if err := c.record(ctx, MethodSend, req); err != nil {
+		return xid.Response{}, err
+	}
+
+	if req.Err != nil {
+		return xid.Response{}, fmt.Errorf("%s: %w", "hem request error", req.Err)
+	}
+
+	c.m.Lock()
+	r, ok := c.send[hemKey(req)]
+	c.m.Unlock()
+
+	if ok {
+		return r.value, r.err
+	}
+
+	return xid.Response{Value: XID(req), Status: xid.Okay}, nil
+}
+
+func (c *Client) RefreshXID(ctx context.Context, req xid.RefreshReq) (xid.RefreshResp, error) {
+	if err := c.record(ctx, MethodRefreshXID, req); err != nil {
+		return xid.RefreshResp{}, err
+	}
+
+	c.m.Lock()
+	r, ok := c.refresh[req.XID]
+	c.m.Unlock()
+
+	if ok {
+		return r.value, r.err
+	}
+
+	return xid.RefreshResp{Value: req.XID, Status: xid.Okay}, nil
+}
+
+// TokenFromXID returns a token which is only encoded, not encrypted.
+func (c *Client) TokenFromXID(_xid string) (xid.Token, error) {
+	if err := c.record(context.Background(), MethodTokenFromXID, _xid); err != nil {
+		return "", err
+	}
+
+	return xid.NewToken(KeyID, xid.Value(_xid)), nil
+}
+
+// DecryptToken decodes tokens made by TokenFromXID.
+func (c *Client) DecryptToken(token xid.Token) (string, error) {
+	if err := c.record(context.Background(), MethodDecryptToken, token); err != nil {
+		return "", err
+	}
+
+	key, err := token.Key()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %w", client.ErrInvalidToken, "key error", err)
+	}
+
+	if key != KeyID {
+		return "", fmt.Errorf("%w: %s: %d", client.ErrInvalidToken, "unknown key", key)
+	}
+
+	v, err := token.XID()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %w", client.ErrInvalidToken, "xid error", err)
+	}
+
+	return string(v), nil
+}
+
+// record records the call and returns the error the method is set to fail
+// with, or the error of ctx.
+func (c *Client) record(ctx context.Context, method string, arg any) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.calls = append(c.calls, Call{Method: method, Arg: arg})
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return c.errs[method]
+}
+
+func hemKey(req hem.Request) string {
+	return req.Type + ":" + req.Value
+}
+
+func orStatusError(err error, status xid.StatusOf) error {
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case xid.StatusOfUserBlocked:
+		return fmt.Errorf("%w, status: %s", client.ErrUserBlocked, status)
+	case xid.StatusOfInvalidConsent:
+		return fmt.Errorf("%w, status: %s", client.ErrConsent, status)
+	case xid.StatusOfOK, xid.StatusOfUnknown:
+		return nil
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,189 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	client "github.com/ceeideu/sdk"
+	"github.com/ceeideu/sdk/hem"
+	"github.com/ceeideu/sdk/xid"
+)
+
+func TestClient_Send(t *testing.T) {
+	t.Parallel()
+
+	req := hem.Request{Type: "email", Value: "hashed"}
+	errService := errors.New("service")
+
+	tests := []struct {
+		name    string
+		script  func(c *Client)
+		req     hem.Request
+		want    xid.Response
+		wantErr error
+	}{
+		{
+			name: "derived",
+			req:  req,
+			want: xid.Response{Value: XID(req), Status: xid.Okay},
+		},
+		{
+			name: "scripted",
+			script: func(c *Client) {
+				c.OnSend(req, xid.Response{Value: "scripted", Status: xid.Okay}, nil)
+			},
+			req:  req,
+			want: xid.Response{Value: "scripted", Status: xid.Okay},
+		},
+		{
+			name: "other hem not scripted",
+			script: func(c *Client) {
+				c.OnSend(req, xid.Response{Value: "scripted", Status: xid.Okay}, nil)
+			},
+			req:  hem.Request{Type: "email", Value: "other"},
+			want: xid.Response{Value: XID(hem.Request{Type: "email", Value: "other"}), Status: xid.Okay},
+		},
+		{
+			name: "blocked",
+			script: func(c *Client) {
+				c.OnSend(req, xid.Response{Status: xid.UserBlocked}, nil)
+			},
+			req:     req,
+			want:    xid.Response{Status: xid.UserBlocked},
+			wantErr: client.ErrUserBlocked,
+		},
+		{
+			name: "consent",
+			script: func(c *Client) {
+				c.OnSend(req, xid.Response{Status: xid.InvalidConsent}, nil)
+			},
+			req:     req,
+			want:    xid.Response{Status: xid.InvalidConsent},
+			wantErr: client.ErrConsent,
+		},
+		{
+			name: "scripted error",
+			script: func(c *Client) {
+				c.OnSend(req, xid.Response{}, errService)
+			},
+			req:     req,
+			wantErr: errService,
+		},
+		{
+			name: "failing",
+			script: func(c *Client) {
+				c.OnSend(req, xid.Response{Value: "scripted", Status: xid.Okay}, nil).Fail(MethodSend, errService)
+			},
+			req:     req,
+			wantErr: errService,
+		},
+	}
+
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := New()
+			if test.script != nil {
+				test.script(c)
+			}
+
+			got, err := c.Send(context.Background(), test.req)
+			if !errors.Is(err, test.wantErr) || (err != nil) != (test.wantErr != nil) {
+				t.Fatalf("Send() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if got != test.want {
+				t.Errorf("Send() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestClient_SendHEMError(t *testing.T) {
+	t.Parallel()
+
+	errHEM := errors.New("hem")
+
+	if _, err := New().Send(context.Background(), hem.Request{Err: errHEM}); !errors.Is(err, errHEM) {
+		t.Errorf("Send() error = %v, want %v", err, errHEM)
+	}
+}
+
+func TestClient_RefreshXID(t *testing.T) {
+	t.Parallel()
+
+	c := New().OnRefreshXID("blocked", xid.RefreshResp{Status: xid.UserBlocked}, nil)
+
+	got, err := c.RefreshXID(context.Background(), xid.RefreshRequest("known"))
+	if err != nil {
+		t.Fatalf("RefreshXID() error = %v", err)
+	}
+
+	if got.Value != "known" || got.Status != xid.Okay {
+		t.Errorf("RefreshXID() = %v, want known xID", got)
+	}
+
+	if _, err := c.RefreshXID(context.Background(), xid.RefreshRequest("blocked")); !errors.Is(err, client.ErrUserBlocked) {
+		t.Errorf("RefreshXID() error = %v, want %v", err, client.ErrUserBlocked)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := c.RefreshXID(ctx, xid.RefreshRequest("known")); !errors.Is(err, context.Canceled) {
+		t.Errorf("RefreshXID() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestClient_Token(t *testing.T) {
+	t.Parallel()
+
+	c := New()
+
+	token, err := c.TokenFromXID("xid")
+	if err != nil {
+		t.Fatalf("TokenFromXID() error = %v", err)
+	}
+
+	got, err := c.DecryptToken(token)
+	if err != nil || got != "xid" {
+		t.Errorf("DecryptToken() = %v, %v, want xid", got, err)
+	}
+
+	if _, err := c.DecryptToken(xid.NewToken(KeyID+1, xid.Value("xid"))); !errors.Is(err, client.ErrInvalidToken) {
+		t.Errorf("DecryptToken() error = %v, want %v", err, client.ErrInvalidToken)
+	}
+
+	if _, err := c.DecryptToken("x"); !errors.Is(err, client.ErrInvalidToken) {
+		t.Errorf("DecryptToken() error = %v, want %v", err, client.ErrInvalidToken)
+	}
+}
+
+func TestClient_Calls(t *testing.T) {
+	t.Parallel()
+
+	c := New()
+	req := hem.Request{Type: "email", Value: "hashed"}
+
+	_, _ = c.Send(context.Background(), req)
+	_, _ = c.TokenFromXID("xid")
+	_, _ = c.Send(context.Background(), req)
+
+	if got := len(c.Calls()); got != 3 {
+		t.Fatalf("len(Calls()) = %d, want 3", got)
+	}
+
+	sends := c.CallsOf(MethodSend)
+	if len(sends) != 2 || sends[0].Arg.(hem.Request).Value != "hashed" {
+		t.Errorf("CallsOf(Send) = %v, want 2 calls", sends)
+	}
+
+	c.Reset()
+
+	if got := len(c.Calls()); got != 0 {
+		t.Errorf("len(Calls()) after Reset() = %d, want 0", got)
+	}
+}
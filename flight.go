@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ceeideu/sdk/xid"
+)
+
+type flights struct {
+	generate flightGroup[xid.Response]
+	refresh  flightGroup[xid.RefreshResp]
+}
+
+// flightGroup coalesces concurrent calls with the same key into a single
+// call whose result is shared by all callers. The zero value is ready to use.
+type flightGroup[T any] struct {
+	calls map[string]*flightCall[T]
+
+	m sync.Mutex
+}
+
+type flightCall[T any] struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+
+	value T
+	err   error
+}
+
+// do runs fn once for all concurrent callers with the same key. fn runs with
+// a context detached from the cancellation of the callers, and is only
+// canceled once every caller has given up waiting.
+func (g *flightGroup[T]) do(ctx context.Context, key string, fn func(context.Context) (T, error)) (T, error) {
+	g.m.Lock()
+
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall[T])
+	}
+
+	call, ok := g.calls[key]
+	if !ok {
+		callCtx, cancel := context.WithCancel(detach(ctx))
+		call = &flightCall[T]{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = call
+
+		go func() {
+			defer cancel()
+
+			call.value, call.err = fn(callCtx)
+
+			g.m.Lock()
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+			g.m.Unlock()
+
+			close(call.done)
+		}()
+	}
+
+	call.waiters++
+	g.m.Unlock()
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		g.leave(key, call)
+
+		var zero T
+
+		return zero, ctx.Err()
+	}
+}
+
+func (g *flightGroup[T]) leave(key string, call *flightCall[T]) {
+	g.m.Lock()
+	defer g.m.Unlock()
+
+	call.waiters--
+	if call.waiters > 0 {
+		return
+	}
+
+	if g.calls[key] == call {
+		delete(g.calls, key)
+	}
+
+	call.cancel()
+}
+
+type callerDeadlineKey struct{}
+
+// detach returns a context carrying the values of ctx but not its
+// cancellation. The deadline of ctx, if any, is kept as a value so that the
+// shared call can run until the later of it and the operation timeout.
+func detach(ctx context.Context) context.Context {
+	detached := context.WithoutCancel(ctx)
+	if deadline, ok := ctx.Deadline(); ok {
+		detached = context.WithValue(detached, callerDeadlineKey{}, deadline)
+	}
+
+	return detached
+}
@@ -0,0 +1,219 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ceeideu/sdk/hem"
+	"github.com/ceeideu/sdk/xid"
+)
+
+func (g *flightGroup[T]) waiting() int {
+	g.m.Lock()
+	defer g.m.Unlock()
+
+	n := 0
+	for _, c := range g.calls {
+		n += c.waiters
+	}
+
+	return n
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func Test_flightGroup(t *testing.T) {
+	t.Parallel()
+
+	var g flightGroup[string]
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (string, error) {
+		calls.Add(1)
+		select {
+		case <-release:
+			return "v", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	canceledErr := make(chan error, 1)
+
+	go func() {
+		_, err := g.do(canceled, "k", fn)
+		canceledErr <- err
+	}()
+
+	waitFor(t, func() bool { return g.waiting() == 1 })
+
+	var wg sync.WaitGroup
+	results := make([]string, 3)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = g.do(context.Background(), "k", fn)
+		}(i)
+	}
+
+	waitFor(t, func() bool { return g.waiting() == 4 })
+	cancel()
+
+	if err := <-canceledErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled waiter error = %v, want %v", err, context.Canceled)
+	}
+
+	close(release)
+	wg.Wait()
+
+	for i, r := range results {
+		if r != "v" {
+			t.Errorf("result %d = %q, want %q", i, r, "v")
+		}
+	}
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("calls = %d, want 1", n)
+	}
+}
+
+func Test_flightGroup_AllWaitersGone(t *testing.T) {
+	t.Parallel()
+
+	var g flightGroup[string]
+	fnErr := make(chan error, 1)
+	fn := func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		fnErr <- ctx.Err()
+
+		return "", ctx.Err()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := g.do(ctx, "k", fn); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("do() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if err := <-fnErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("shared call error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestXID_SendCoalesced(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+
+		if r.URL.Path == XidRefresh {
+			m, _ := json.Marshal(xid.RefreshResp{Value: "refreshed", Status: xid.Okay})
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(m)
+
+			return
+		}
+
+		m, _ := json.Marshal(xid.Response{Value: "xid", Status: xid.Okay})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(m)
+	}))
+	defer ts.Close()
+
+	xidClient, _ := NewXID(ts.URL, XApiMockValue, WithHTTPClient(ts.Client()))
+
+	const n = 5
+
+	var wg sync.WaitGroup
+	sent := make([]xid.Response, n)
+	refreshed := make([]xid.RefreshResp, n)
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			sent[i], _ = xidClient.Send(context.Background(), hem.FromEmail("test@com"))
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			refreshed[i], _ = xidClient.RefreshXID(context.Background(), xid.RefreshRequest("xid"))
+		}(i)
+	}
+
+	waitFor(t, func() bool {
+		return xidClient.flights.generate.waiting() == n && xidClient.flights.refresh.waiting() == n
+	})
+	close(release)
+	wg.Wait()
+
+	if h := hits.Load(); h != 2 {
+		t.Errorf("server hits = %d, want 2", h)
+	}
+
+	for i := 0; i < n; i++ {
+		if sent[i].Value != "xid" || refreshed[i].Value != "refreshed" {
+			t.Errorf("caller %d got %v and %v", i, sent[i], refreshed[i])
+		}
+	}
+}
+
+func TestXID_SendCoalescedTimeout(t *testing.T) {
+	t.Parallel()
+
+	hang := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-hang:
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+	defer close(hang)
+
+	timeouts := DefaultTimeouts()
+	timeouts.Generate = 100 * time.Millisecond
+
+	xidClient, _ := NewXID(ts.URL, XApiMockValue, WithHTTPClient(ts.Client()), WithTimeouts(timeouts))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	go func() {
+		_, _ = xidClient.Send(ctx, hem.FromEmail("test@com"))
+	}()
+
+	waitFor(t, func() bool { return xidClient.flights.generate.waiting() == 1 })
+
+	// the caller without a deadline joins the shared call, bound by the operation timeout
+	start := time.Now()
+
+	if _, err := xidClient.Send(context.Background(), hem.FromEmail("test@com")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("XID.Send() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("XID.Send() took %v, want the operation timeout", elapsed)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/mail"
 	"strings"
 
@@ -30,6 +31,19 @@ func (r Request) WithProperties(_properties properties.Value) Request {
 	return r
 }
 
+// LogValue implements slog.LogValuer, redacting the hashed value.
+func (r Request) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("type", r.Type),
+		slog.String("value", properties.Redacted),
+		slog.Any("properties", properties.Value(r.Properties)),
+	)
+}
+
+func (r Request) Identifier() xid.Identifier {
+	return xid.Identifier{Type: r.Type, Value: r.Value}
+}
+
 func FromEmail(email string) Request {
 	em, err := NormalizeEmail(email)
 
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+const (
+	OpGenerate      = "generate"
+	OpRefresh       = "refresh"
+	OpKeys          = "keys"
+	OpMap           = "map"
+	OpLookup        = "lookup"
+	OpDecode        = "decode"
+	OpToken         = "token"
+	OpTokenRefresh  = "token_refresh"
+	OpGenerateBatch = "generate_batch"
+	OpRefreshBatch  = "refresh_batch"
+	// OpProbe is the operation of the health probes of the endpoints.
+	OpProbe = "probe"
+)
+
+// HTTPDoerFunc adapts a function to the HTTPDoer interface.
+type HTTPDoerFunc func(req *http.Request) (*http.Response, error)
+
+func (f HTTPDoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Interceptor wraps the HTTPDoer used for outgoing requests, e.g. to add
+// headers, logging or tracing. The operation of a request is available
+// through OperationFromContext(req.Context()).
+type Interceptor func(next HTTPDoer) HTTPDoer
+
+// WithInterceptor adds interceptors applied to every request attempt. The
+// first interceptor given is the outermost one.
+func WithInterceptor(interceptors ...Interceptor) func(*XID) {
+	return func(x *XID) {
+		x.interceptors = append(x.interceptors, interceptors...)
+	}
+}
+
+func chain(doer HTTPDoer, interceptors []Interceptor) HTTPDoer {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		doer = interceptors[i](doer)
+	}
+
+	return doer
+}
+
+type operationKey struct{}
+
+// WithOperation sets the operation name of requests made with ctx. Requests
+// to the known endpoints get their operation name set automatically.
+func WithOperation(ctx context.Context, op string) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+func OperationFromContext(ctx context.Context) string {
+	op, _ := ctx.Value(operationKey{}).(string)
+
+	return op
+}
+
+func operationOf(path string) string {
+	switch path {
+	case XidGenerate:
+		return OpGenerate
+	case XidRefresh:
+		return OpRefresh
+	case KeysRefresh:
+		return OpKeys
+	case XidMap:
+		return OpMap
+	case XidLookup:
+		return OpLookup
+	case XidDecode:
+		return OpDecode
+	case Token:
+		return OpToken
+	case TokenRefresh:
+		return OpTokenRefresh
+	case XidGenerateBatch:
+		return OpGenerateBatch
+	case XidRefreshBatch:
+		return OpRefreshBatch
+	default:
+		return ""
+	}
+}
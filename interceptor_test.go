@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/ceeideu/sdk/hem"
+	"github.com/ceeideu/sdk/xid"
+)
+
+func TestWithInterceptor(t *testing.T) {
+	t.Parallel()
+
+	var (
+		m     sync.Mutex
+		calls []string
+	)
+
+	record := func(name string) Interceptor {
+		return func(next HTTPDoer) HTTPDoer {
+			return HTTPDoerFunc(func(req *http.Request) (*http.Response, error) {
+				m.Lock()
+				calls = append(calls, name+":"+OperationFromContext(req.Context()))
+				m.Unlock()
+				req.Header.Add("x-interceptor", name)
+
+				return next.Do(req)
+			})
+		}
+	}
+
+	var headers []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Values("x-interceptor")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"value":"xid"}`))
+	}))
+	defer ts.Close()
+
+	xidClient, _ := NewXID(ts.URL, XApiMockValue, WithHTTPClient(ts.Client()),
+		WithInterceptor(record("outer")), WithInterceptor(record("inner")))
+
+	_, _ = xidClient.Send(context.Background(), hem.FromEmail("test@com"))
+	_, _ = xidClient.RefreshXID(context.Background(), xid.RefreshRequest("xid"))
+	_ = xidClient.Refresh(context.Background())
+	resp, err := xidClient.DoHTTPReq(WithOperation(context.Background(), "custom"), http.MethodGet, ts.URL+"/other", nil)
+	if err == nil {
+		resp.Body.Close()
+	}
+
+	want := []string{
+		"outer:" + OpGenerate, "inner:" + OpGenerate,
+		"outer:" + OpRefresh, "inner:" + OpRefresh,
+		"outer:" + OpKeys, "inner:" + OpKeys,
+		"outer:custom", "inner:custom",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("interceptor calls = %v, want %v", calls, want)
+	}
+
+	if !reflect.DeepEqual(headers, []string{"outer", "inner"}) {
+		t.Errorf("interceptor headers = %v, want %v", headers, []string{"outer", "inner"})
+	}
+}
+
+func Test_operationOf(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		baseURL string
+		url     string
+		want    string
+	}{
+		{name: "generate", baseURL: "https://ceeid.eu", url: "https://ceeid.eu/xid/generate", want: OpGenerate},
+		{name: "base path", baseURL: "https://ceeid.eu/api/", url: "https://ceeid.eu/api/keys/refresh", want: OpKeys},
+		{name: "unknown", baseURL: "https://ceeid.eu", url: "https://ceeid.eu/foo", want: ""},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			xidClient, _ := NewXID(test.baseURL, XApiMockValue)
+			req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, test.url, nil)
+			if got := operationOf(xidClient.endpointPath(req.URL)); got != test.want {
+				t.Errorf("operationOf() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	IfNoneMatchHeader     = "If-None-Match"
+	IfModifiedSinceHeader = "If-Modified-Since"
+	ETagHeader            = "ETag"
+	LastModifiedHeader    = "Last-Modified"
+	CacheControlHeader    = "Cache-Control"
+	ExpiresHeader         = "Expires"
+	AgeHeader             = "Age"
+
+	maxAgeDirective = "max-age="
+)
+
+// keysCache holds the validators and freshness of the last applied keys response.
+type keysCache struct {
+	etag         string
+	lastModified string
+	expires      time.Time
+	// confirmed is when the keys were last fetched or revalidated.
+	confirmed time.Time
+	// keyID is the encryption key of the applied keys, if any.
+	keyID   uint8
+	hasKeys bool
+}
+
+func newKeysCache(h http.Header, now time.Time) keysCache {
+	return keysCache{
+		etag:         h.Get(ETagHeader),
+		lastModified: h.Get(LastModifiedHeader),
+		expires:      expiresAt(h, now),
+		confirmed:    now,
+	}
+}
+
+func (c keysCache) fresh(now time.Time) bool {
+	return now.Before(c.expires)
+}
+
+func (c keysCache) conditional(h http.Header) {
+	if c.etag != "" {
+		h.Set(IfNoneMatchHeader, c.etag)
+	}
+
+	if c.lastModified != "" {
+		h.Set(IfModifiedSinceHeader, c.lastModified)
+	}
+}
+
+// revalidated returns the cache updated with the headers of a 304 response.
+func (c keysCache) revalidated(h http.Header, now time.Time) keysCache {
+	if etag := h.Get(ETagHeader); etag != "" {
+		c.etag = etag
+	}
+
+	if lastModified := h.Get(LastModifiedHeader); lastModified != "" {
+		c.lastModified = lastModified
+	}
+
+	c.expires = expiresAt(h, now)
+	c.confirmed = now
+
+	return c
+}
+
+// expiresAt computes until when a response is fresh, following Cache-Control
+// max-age (minus Age) with a fallback to Expires. Without either the response
+// is stale immediately.
+func expiresAt(h http.Header, now time.Time) time.Time {
+	for _, directive := range strings.Split(h.Get(CacheControlHeader), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+
+		switch {
+		case directive == "no-cache" || directive == "no-store":
+			return now
+		case strings.HasPrefix(directive, maxAgeDirective):
+			maxAge, err := strconv.Atoi(strings.TrimPrefix(directive, maxAgeDirective))
+			if err != nil {
+				return now
+			}
+
+			age, _ := strconv.Atoi(h.Get(AgeHeader))
+
+			return now.Add(time.Duration(maxAge-age) * time.Second)
+		}
+	}
+
+	if expires, err := http.ParseTime(h.Get(ExpiresHeader)); err == nil {
+		return expires
+	}
+
+	return now
+}
+
+type keysState struct {
+	cache keysCache
+
+	m sync.Mutex
+}
+
+func (s *keysState) get() keysCache {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.cache
+}
+
+func (s *keysState) set(c keysCache) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.cache = c
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func Test_expiresAt(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Time
+	}{
+		{
+			name:   "no headers",
+			header: http.Header{},
+			want:   now,
+		},
+		{
+			name:   "max-age",
+			header: http.Header{CacheControlHeader: []string{"public, max-age=60"}},
+			want:   now.Add(time.Minute),
+		},
+		{
+			name:   "max-age with age",
+			header: http.Header{CacheControlHeader: []string{"max-age=60"}, AgeHeader: []string{"20"}},
+			want:   now.Add(40 * time.Second),
+		},
+		{
+			name:   "no-cache",
+			header: http.Header{CacheControlHeader: []string{"no-cache, max-age=60"}},
+			want:   now,
+		},
+		{
+			name:   "bad max-age",
+			header: http.Header{CacheControlHeader: []string{"max-age=foo"}},
+			want:   now,
+		},
+		{
+			name:   "expires",
+			header: http.Header{ExpiresHeader: []string{now.Add(time.Hour).Format(http.TimeFormat)}},
+			want:   now.Add(time.Hour),
+		},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if got := expiresAt(test.header, now); !got.Equal(test.want) {
+				t.Errorf("expiresAt() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func Test_keysCache_conditional(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		cache keysCache
+		want  http.Header
+	}{
+		{
+			name:  "empty",
+			cache: keysCache{},
+			want:  http.Header{},
+		},
+		{
+			name:  "validators",
+			cache: keysCache{etag: `"v1"`, lastModified: "Mon, 01 Jan 2024 12:00:00 GMT"},
+			want: http.Header{
+				IfNoneMatchHeader:     []string{`"v1"`},
+				IfModifiedSinceHeader: []string{"Mon, 01 Jan 2024 12:00:00 GMT"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			got := http.Header{}
+			test.cache.conditional(got)
+			if len(got) != len(test.want) {
+				t.Fatalf("conditional() = %v, want %v", got, test.want)
+			}
+			for k := range test.want {
+				if got.Get(k) != test.want.Get(k) {
+					t.Errorf("conditional() %s = %v, want %v", k, got.Get(k), test.want.Get(k))
+				}
+			}
+		})
+	}
+}
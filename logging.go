@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ceeideu/sdk/xid"
+)
+
+const (
+	LogOperation     = "operation"
+	LogEndpoint      = "endpoint"
+	LogMethod        = "method"
+	LogAttempt       = "attempt"
+	LogStatusCode    = "status_code"
+	LogStatus        = "status"
+	LogDuration      = "duration"
+	LogDelay         = "delay"
+	LogErrorClass    = "error_class"
+	LogError         = "error"
+	LogKeyID         = "key_id"
+	LogPreviousKeyID = "previous_key_id"
+	LogBaseURL       = "base_url"
+	LogHealthy       = "healthy"
+)
+
+// WithLogger makes the client log request lifecycle, retries, key rotations
+// and decrypt failures. HEM values, xIDs, tokens, IPs, user agents and the API
+// key are never logged: requests log through their redacting LogValue methods
+// and errors of calls carrying personal data are only logged by class.
+func WithLogger(l *slog.Logger) func(*XID) {
+	return func(x *XID) {
+		x.logger = l
+	}
+}
+
+func (x *XID) log() *slog.Logger {
+	if x.logger == nil {
+		return slog.New(discardHandler{})
+	}
+
+	return x.logger
+}
+
+func (x *XID) logAttempt(ctx context.Context, req *http.Request, attempt int, resp *http.Response, err error, elapsed time.Duration) {
+	attrs := []slog.Attr{
+		slog.String(LogOperation, OperationFromContext(ctx)),
+		slog.String(LogMethod, req.Method),
+		slog.String(LogEndpoint, x.endpointPath(req.URL)),
+		slog.Int(LogAttempt, attempt+1),
+		slog.Duration(LogDuration, elapsed),
+	}
+
+	if err != nil {
+		x.log().LogAttrs(ctx, slog.LevelDebug, "ceeid request failed", append(attrs, slog.String(LogErrorClass, errorClass(err)))...)
+
+		return
+	}
+
+	x.log().LogAttrs(ctx, slog.LevelDebug, "ceeid request", append(attrs, slog.Int(LogStatusCode, resp.StatusCode))...)
+}
+
+func (x *XID) logRetry(ctx context.Context, req *http.Request, attempt int, resp *http.Response, err error, delay time.Duration) {
+	reason := errorClass(err)
+	if err == nil {
+		reason = "http_" + strconv.Itoa(resp.StatusCode)
+	}
+
+	x.log().LogAttrs(ctx, slog.LevelWarn, "ceeid request retry",
+		slog.String(LogOperation, OperationFromContext(ctx)),
+		slog.String(LogEndpoint, x.endpointPath(req.URL)),
+		slog.Int(LogAttempt, attempt+1),
+		slog.Duration(LogDelay, delay),
+		slog.String(LogErrorClass, reason),
+	)
+}
+
+func (x *XID) logResult(ctx context.Context, op string, request slog.Attr, status xid.StatusOf, err error) {
+	if err != nil {
+		x.log().LogAttrs(ctx, slog.LevelWarn, "ceeid "+op+" failed",
+			request, slog.String(LogStatus, status.String()), slog.String(LogErrorClass, errorClass(err)))
+
+		return
+	}
+
+	x.log().LogAttrs(ctx, slog.LevelDebug, "ceeid "+op, request, slog.String(LogStatus, status.String()))
+}
+
+func (x *XID) logRefresh(ctx context.Context, outcome refreshOutcome, err error) {
+	switch {
+	case err != nil:
+		// keys errors carry no personal data, so the message is logged too.
+		x.log().LogAttrs(ctx, slog.LevelWarn, "ceeid keys refresh failed",
+			slog.String(LogErrorClass, errorClass(err)), slog.String(LogError, err.Error()))
+	case outcome.rotated:
+		x.log().LogAttrs(ctx, slog.LevelInfo, "ceeid encryption key rotated",
+			slog.Int(LogPreviousKeyID, int(outcome.previousKeyID)), slog.Int(LogKeyID, int(outcome.keyID)))
+	case outcome.applied:
+		x.log().LogAttrs(ctx, slog.LevelInfo, "ceeid keys refreshed", slog.Int(LogKeyID, int(outcome.keyID)))
+	default:
+		x.log().LogAttrs(ctx, slog.LevelDebug, "ceeid keys current",
+			slog.Bool("fresh", outcome.fresh), slog.Bool("not_modified", outcome.notModified))
+	}
+}
+
+// discardHandler drops all records, slog.DiscardHandler is not available before Go 1.24.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool { return false }
+
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h discardHandler) WithGroup(string) slog.Handler { return h }
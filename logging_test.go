@@ -0,0 +1,105 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ceeideu/sdk/hem"
+	"github.com/ceeideu/sdk/properties"
+	"github.com/ceeideu/sdk/xid"
+)
+
+type syncBuffer struct {
+	b bytes.Buffer
+	m sync.Mutex
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.b.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.b.String()
+}
+
+func TestWithLogger(t *testing.T) {
+	t.Parallel()
+
+	const (
+		apiKey = "secret-api-key"
+		ip     = "1.2.3.4"
+		ua     = "Mozilla/5.0 test-agent"
+	)
+
+	var keyID uint8 = 1
+	var generateCalls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case XidGenerate:
+			generateCalls++
+			if generateCalls == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"value":"xid","status":"ok"}`))
+		case KeysRefresh:
+			m, _ := json.Marshal(KeysResp{Encryption: Encryption{ID: keyID, Value: testKey}})
+			keyID++
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(m)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	buf := &syncBuffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	xidClient, _ := NewXID(ts.URL, apiKey, WithHTTPClient(ts.Client()), WithLogger(logger),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}))
+
+	hemReq := hem.FromEmail("test@com").WithProperties(properties.WithConsent("TCF").WithIP(ip).WithUserAgent(ua))
+	ctx := WithIdempotencyKey(context.Background(), "login-1")
+	_, _ = xidClient.Send(ctx, hemReq)
+	_, _ = xidClient.RefreshXID(context.Background(), xid.RefreshRequest("xid-value").WithProperties(properties.WithConsent("TCF").WithIP(ip)))
+	_ = xidClient.Refresh(context.Background())
+	_ = xidClient.Refresh(context.Background())
+	_, _ = xidClient.DecryptToken(xid.NewToken(9, xid.Value("foo")))
+
+	out := buf.String()
+	for _, secret := range []string{hemReq.Value, ip, ua, apiKey, "xid-value"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("log output contains %q:\n%s", secret, out)
+		}
+	}
+
+	for _, msg := range []string{
+		`"msg":"ceeid request retry"`,
+		`"msg":"ceeid generate"`,
+		`"msg":"ceeid refresh failed"`,
+		`"msg":"ceeid keys refreshed"`,
+		`"msg":"ceeid encryption key rotated"`,
+		`"msg":"ceeid token decrypt failed"`,
+		`"consent":"TCF"`,
+	} {
+		if !strings.Contains(out, msg) {
+			t.Errorf("log output does not contain %s:\n%s", msg, out)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ceeideu/sdk/metrics"
+)
+
+const (
+	refreshFresh       = "fresh"
+	refreshNotModified = "not_modified"
+	refreshApplied     = "applied"
+	refreshError       = "error"
+)
+
+// WithMetrics makes the client and its crypto service report request, crypto
+// and key refresh telemetry.
+func WithMetrics(m metrics.Metrics) func(*XID) {
+	return func(x *XID) {
+		x.metrics = m
+	}
+}
+
+func (x *XID) meter() metrics.Metrics {
+	return metrics.OrNoop(x.metrics)
+}
+
+func (x *XID) observeRequest(ctx context.Context, resp *http.Response, err error, elapsed time.Duration) {
+	code := errorClass(err)
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+
+	op := metrics.Label{Name: metrics.LabelOperation, Value: OperationFromContext(ctx)}
+
+	x.meter().IncCounter(metrics.RequestsTotal, op, metrics.Label{Name: metrics.LabelCode, Value: code})
+	x.meter().ObserveHistogram(metrics.RequestDuration, elapsed.Seconds(), op)
+}
+
+func (x *XID) observeRefresh(outcome refreshOutcome, err error) {
+	result := refreshError
+
+	switch {
+	case err != nil:
+	case outcome.fresh:
+		result = refreshFresh
+	case outcome.notModified:
+		result = refreshNotModified
+	case outcome.applied:
+		result = refreshApplied
+	}
+
+	x.meter().IncCounter(metrics.KeysRefreshTotal, metrics.Label{Name: metrics.LabelResult, Value: result})
+
+	if confirmed := x.keys.get().confirmed; !confirmed.IsZero() {
+		x.meter().SetGauge(metrics.KeysAge, x.clock().Sub(confirmed).Seconds())
+	}
+}
@@ -0,0 +1,64 @@
+// Package metrics defines the dependency-free metrics interface used by the
+// SDK, together with a Prometheus-style reference implementation.
+package metrics
+
+const (
+	// RequestsTotal counts request attempts by operation and code, the HTTP
+	// status code or the error class of a failed attempt.
+	RequestsTotal = "ceeid_requests_total"
+	// RequestDuration observes request attempt latency in seconds by operation.
+	RequestDuration = "ceeid_request_duration_seconds"
+	// DecryptFailuresTotal counts token decryption failures by reason.
+	DecryptFailuresTotal = "ceeid_decrypt_failures_total"
+	// KeysRefreshTotal counts key refreshes by result.
+	KeysRefreshTotal = "ceeid_keys_refresh_total"
+	// KeysAge is the number of seconds since the keys were last confirmed current.
+	KeysAge = "ceeid_keys_age_seconds"
+	// CryptoOperationsTotal counts encryptions and decryptions by operation and result.
+	CryptoOperationsTotal = "ceeid_crypto_operations_total"
+	// KeysUpdated is the unix time of the last update of the keys.
+	KeysUpdated = "ceeid_keys_updated_timestamp_seconds"
+	// DecryptionKeys is the number of decryption keys held.
+	DecryptionKeys = "ceeid_decryption_keys"
+	// CacheRequestsTotal counts response cache lookups by result, hit or miss.
+	CacheRequestsTotal = "ceeid_cache_requests_total"
+	// OutboxDepth is the number of requests waiting in the outbox.
+	OutboxDepth = "ceeid_outbox_depth"
+	// EndpointHealthy is 1 when the endpoint is healthy, 0 otherwise.
+	EndpointHealthy = "ceeid_endpoint_healthy"
+
+	LabelOperation = "operation"
+	LabelCode      = "code"
+	LabelReason    = "reason"
+	LabelResult    = "result"
+	LabelEndpoint  = "endpoint"
+)
+
+type Label struct {
+	Name  string
+	Value string
+}
+
+type Metrics interface {
+	IncCounter(name string, labels ...Label)
+	ObserveHistogram(name string, value float64, labels ...Label)
+	SetGauge(name string, value float64, labels ...Label)
+}
+
+// Noop is a Metrics doing nothing.
+type Noop struct{}
+
+func (Noop) IncCounter(string, ...Label) {}
+
+func (Noop) ObserveHistogram(string, float64, ...Label) {}
+
+func (Noop) SetGauge(string, float64, ...Label) {}
+
+// OrNoop returns m, or Noop when m is nil.
+func OrNoop(m Metrics) Metrics {
+	if m == nil {
+		return Noop{}
+	}
+
+	return m
+}
@@ -0,0 +1,239 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+
+	contentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// DefaultBuckets are the histogram buckets used by NewRegistry when none are given.
+func DefaultBuckets() []float64 {
+	return []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+}
+
+// Registry is an in-memory Metrics exposing its values in the Prometheus
+// text format, e.g. by mounting it as an http.Handler on /metrics.
+type Registry struct {
+	buckets []float64
+	// families by metric name.
+	families map[string]*family
+
+	m sync.Mutex
+}
+
+type family struct {
+	typ string
+	// series by rendered labels.
+	series map[string]*series
+}
+
+type series struct {
+	value   float64
+	sum     float64
+	count   uint64
+	buckets []uint64
+}
+
+func NewRegistry(buckets ...float64) *Registry {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets()
+	}
+
+	sort.Float64s(buckets)
+
+	return &Registry{buckets: buckets, families: map[string]*family{}}
+}
+
+func (r *Registry) IncCounter(name string, labels ...Label) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.series(name, typeCounter, labels).value++
+}
+
+func (r *Registry) ObserveHistogram(name string, value float64, labels ...Label) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	s := r.series(name, typeHistogram, labels)
+	if s.buckets == nil {
+		s.buckets = make([]uint64, len(r.buckets))
+	}
+
+	for i, le := range r.buckets {
+		if value <= le {
+			s.buckets[i]++
+		}
+	}
+
+	s.sum += value
+	s.count++
+}
+
+func (r *Registry) SetGauge(name string, value float64, labels ...Label) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.series(name, typeGauge, labels).value = value
+}
+
+// Value returns the value of a counter or gauge, or the count of a histogram.
+func (r *Registry) Value(name string, labels ...Label) float64 {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	f, ok := r.families[name]
+	if !ok {
+		return 0
+	}
+
+	s, ok := f.series[renderLabels(labels)]
+	if !ok {
+		return 0
+	}
+
+	if f.typ == typeHistogram {
+		return float64(s.count)
+	}
+
+	return s.value
+}
+
+func (r *Registry) series(name, typ string, labels []Label) *series {
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{typ: typ, series: map[string]*series{}}
+		r.families[name] = f
+	}
+
+	key := renderLabels(labels)
+
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{}
+		f.series[key] = s
+	}
+
+	return s
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", contentType)
+	_, _ = r.WriteTo(w)
+}
+
+// WriteTo writes all metrics in the Prometheus text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+
+	for _, name := range sortedKeys(r.families) {
+		f := r.families[name]
+		fmt.Fprintf(cw, "# TYPE %s %s\n", name, f.typ)
+
+		for _, labels := range sortedKeys(f.series) {
+			s := f.series[labels]
+			if f.typ != typeHistogram {
+				fmt.Fprintf(cw, "%s%s %s\n", name, braces(labels), formatFloat(s.value))
+
+				continue
+			}
+
+			for i, le := range r.buckets {
+				fmt.Fprintf(cw, "%s_bucket%s %d\n", name, braces(join(labels, `le="`+formatFloat(le)+`"`)), s.buckets[i])
+			}
+
+			fmt.Fprintf(cw, "%s_bucket%s %d\n", name, braces(join(labels, `le="+Inf"`)), s.count)
+			fmt.Fprintf(cw, "%s_sum%s %s\n", name, braces(labels), formatFloat(s.sum))
+			fmt.Fprintf(cw, "%s_count%s %d\n", name, braces(labels), s.count)
+		}
+	}
+
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+
+	return cw.n, cw.w.Flush()
+}
+
+func renderLabels(labels []Label) string {
+	sorted := make([]Label, len(labels))
+	copy(sorted, labels)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	parts := make([]string, 0, len(sorted))
+	for _, l := range sorted {
+		parts = append(parts, l.Name+"="+strconv.Quote(l.Value))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func join(labels, label string) string {
+	if labels == "" {
+		return label
+	}
+
+	return labels + "," + label
+}
+
+func braces(labels string) string {
+	if labels == "" {
+		return ""
+	}
+
+	return "{" + labels + "}"
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+
+	return n, err
+}
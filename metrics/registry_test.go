@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		record func(r *Registry)
+		want   string
+	}{
+		{
+			name:   "empty",
+			record: func(r *Registry) {},
+			want:   "",
+		},
+		{
+			name: "counter",
+			record: func(r *Registry) {
+				r.IncCounter(RequestsTotal, Label{LabelOperation, "generate"}, Label{LabelCode, "200"})
+				r.IncCounter(RequestsTotal, Label{LabelCode, "200"}, Label{LabelOperation, "generate"})
+				r.IncCounter(RequestsTotal, Label{LabelOperation, "keys"}, Label{LabelCode, "304"})
+			},
+			want: `# TYPE ceeid_requests_total counter
+ceeid_requests_total{code="200",operation="generate"} 2
+ceeid_requests_total{code="304",operation="keys"} 1
+`,
+		},
+		{
+			name: "gauge",
+			record: func(r *Registry) {
+				r.SetGauge(KeysAge, 10)
+				r.SetGauge(KeysAge, 2.5)
+			},
+			want: `# TYPE ceeid_keys_age_seconds gauge
+ceeid_keys_age_seconds 2.5
+`,
+		},
+		{
+			name: "histogram",
+			record: func(r *Registry) {
+				r.ObserveHistogram(RequestDuration, 0.05, Label{LabelOperation, "generate"})
+				r.ObserveHistogram(RequestDuration, 2, Label{LabelOperation, "generate"})
+			},
+			want: `# TYPE ceeid_request_duration_seconds histogram
+ceeid_request_duration_seconds_bucket{operation="generate",le="0.1"} 1
+ceeid_request_duration_seconds_bucket{operation="generate",le="1"} 1
+ceeid_request_duration_seconds_bucket{operation="generate",le="+Inf"} 2
+ceeid_request_duration_seconds_sum{operation="generate"} 2.05
+ceeid_request_duration_seconds_count{operation="generate"} 2
+`,
+		},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			r := NewRegistry(1, 0.1)
+			test.record(r)
+
+			var b strings.Builder
+			n, err := r.WriteTo(&b)
+			if err != nil {
+				t.Fatalf("WriteTo() error = %v", err)
+			}
+			if b.String() != test.want || int(n) != len(test.want) {
+				t.Errorf("WriteTo() = %d, %q, want %q", n, b.String(), test.want)
+			}
+		})
+	}
+}
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry()
+	r.IncCounter(DecryptFailuresTotal, Label{LabelReason, "key_not_present"})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if !strings.Contains(rec.Body.String(), `ceeid_decrypt_failures_total{reason="key_not_present"} 1`) {
+		t.Errorf("ServeHTTP() body = %q", rec.Body.String())
+	}
+
+	if got := r.Value(DecryptFailuresTotal, Label{LabelReason, "key_not_present"}); got != 1 {
+		t.Errorf("Value() = %v, want 1", got)
+	}
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ceeideu/sdk/hem"
+	"github.com/ceeideu/sdk/metrics"
+	"github.com/ceeideu/sdk/xid"
+)
+
+func TestWithMetrics(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case XidGenerate:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"value":"xid","status":"ok"}`))
+		case KeysRefresh:
+			w.Header().Set(CacheControlHeader, "max-age=60")
+			m, _ := json.Marshal(KeysResp{
+				Decryption: map[uint8]string{1: testKey},
+				Encryption: Encryption{ID: 1, Value: testKey},
+			})
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(m)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	registry := metrics.NewRegistry()
+	xidClient, _ := NewXID(ts.URL, XApiMockValue, WithHTTPClient(ts.Client()), WithMetrics(registry))
+	xidClient.now = func() time.Time { return now }
+
+	_, _ = xidClient.Send(context.Background(), hem.FromEmail("test@com"))
+	_, _ = xidClient.RefreshXID(context.Background(), xid.RefreshRequest("xid"))
+	_ = xidClient.Refresh(context.Background())
+	now = now.Add(10 * time.Second)
+	_ = xidClient.Refresh(context.Background())
+	token, _ := xidClient.TokenFromXID("xid")
+	_, _ = xidClient.DecryptToken(token)
+	_, _ = xidClient.DecryptToken(xid.NewToken(9, xid.Value("foo")))
+	_, _ = xidClient.DecryptToken(xid.Token("x"))
+
+	op := func(v string) metrics.Label { return metrics.Label{Name: metrics.LabelOperation, Value: v} }
+	code := func(v string) metrics.Label { return metrics.Label{Name: metrics.LabelCode, Value: v} }
+	reason := func(v string) metrics.Label { return metrics.Label{Name: metrics.LabelReason, Value: v} }
+	result := func(v string) metrics.Label { return metrics.Label{Name: metrics.LabelResult, Value: v} }
+
+	tests := []struct {
+		name   string
+		metric string
+		labels []metrics.Label
+		want   float64
+	}{
+		{name: "generate ok", metric: metrics.RequestsTotal, labels: []metrics.Label{op(OpGenerate), code("200")}, want: 1},
+		{name: "refresh unavailable", metric: metrics.RequestsTotal, labels: []metrics.Label{op(OpRefresh), code("503")}, want: 1},
+		{name: "keys ok", metric: metrics.RequestsTotal, labels: []metrics.Label{op(OpKeys), code("200")}, want: 1},
+		{name: "generate latency", metric: metrics.RequestDuration, labels: []metrics.Label{op(OpGenerate)}, want: 1},
+		{name: "keys applied", metric: metrics.KeysRefreshTotal, labels: []metrics.Label{result(refreshApplied)}, want: 1},
+		{name: "keys fresh", metric: metrics.KeysRefreshTotal, labels: []metrics.Label{result(refreshFresh)}, want: 1},
+		{name: "keys age", metric: metrics.KeysAge, want: 10},
+		{name: "decryption keys", metric: metrics.DecryptionKeys, want: 1},
+		{name: "key not present", metric: metrics.DecryptFailuresTotal, labels: []metrics.Label{reason("key_not_present")}, want: 1},
+		{name: "invalid token", metric: metrics.DecryptFailuresTotal, labels: []metrics.Label{reason("invalid_token")}, want: 1},
+		{
+			name:   "crypto decrypt ok",
+			metric: metrics.CryptoOperationsTotal,
+			labels: []metrics.Label{op("decrypt"), result("ok")},
+			want:   1,
+		},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if got := registry.Value(test.metric, test.labels...); got != test.want {
+				t.Errorf("%s%v = %v, want %v", test.metric, test.labels, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ceeideu/sdk/hem"
+	"github.com/ceeideu/sdk/metrics"
+	"github.com/ceeideu/sdk/outbox"
+	"github.com/ceeideu/sdk/properties"
+)
+
+const (
+	DefaultOutboxInterval   = 5 * time.Second
+	DefaultOutboxMaxBackoff = 5 * time.Minute
+
+	outboxJitter = 0.1
+)
+
+var (
+	ErrQueued        = errors.New("queued in outbox")
+	ErrOutboxDropped = errors.New("outbox request dropped")
+)
+
+// WithOutbox makes Send queue requests failing because the service is
+// unreachable, returning an error wrapping ErrQueued. Queued requests are
+// replayed in order by the background loop run by Start.
+func WithOutbox(q outbox.Queue) func(*XID) {
+	return func(x *XID) {
+		x.outbox.queue = q
+	}
+}
+
+// WithOutboxInterval sets how often the outbox is checked for queued requests.
+func WithOutboxInterval(d time.Duration) func(*XID) {
+	return func(x *XID) {
+		x.outbox.interval = d
+	}
+}
+
+// WithOutboxErrorHandler sets the callback receiving replay errors. Requests
+// rejected by the service are dropped and reported wrapping ErrOutboxDropped.
+func WithOutboxErrorHandler(fn func(error)) func(*XID) {
+	return func(x *XID) {
+		x.outbox.onError = fn
+	}
+}
+
+type outboxConfig struct {
+	queue    outbox.Queue
+	interval time.Duration
+	onError  func(error)
+}
+
+// OutboxLen returns the number of requests waiting in the outbox.
+func (x *XID) OutboxLen() int {
+	if x.outbox.queue == nil {
+		return 0
+	}
+
+	return x.outbox.queue.Len()
+}
+
+// deferrable reports whether a generate call failed because the service is
+// unreachable or unavailable, so that it is worth replaying later.
+func deferrable(err error) bool {
+	var apiErr *APIError
+
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+	case errors.Is(err, context.Canceled):
+		return false
+	default:
+		return errors.Is(err, ErrCommunication) || errors.Is(err, ErrCircuitOpen) ||
+			errors.Is(err, context.DeadlineExceeded)
+	}
+}
+
+// enqueue queues a failed generate call, returning err wrapped in ErrQueued
+// when the request was queued. It runs within the shared call, so concurrent
+// identical requests are queued once. Only the consent is kept of the
+// properties, so that no personal data is persisted.
+func (x *XID) enqueue(ctx context.Context, hemReq hem.Request, err error) error {
+	if x.outbox.queue == nil || !deferrable(err) {
+		return err
+	}
+
+	key := idempotencyKeyFrom(ctx)
+	if key == "" {
+		key = newOutboxKey()
+	}
+
+	hemReq.Properties = properties.Value(hemReq.Properties).WithoutPersonalData()
+
+	if pushErr := x.outbox.queue.Push(outbox.Entry{Key: key, Request: hemReq}); pushErr != nil {
+		x.log().WarnContext(ctx, "ceeid outbox push failed", slog.String(LogError, pushErr.Error()))
+
+		return err
+	}
+
+	x.observeOutbox()
+
+	return fmt.Errorf("%w: %w", ErrQueued, err)
+}
+
+func newOutboxKey() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+func (x *XID) observeOutbox() {
+	x.meter().SetGauge(metrics.OutboxDepth, float64(x.outbox.queue.Len()))
+}
+
+func (x *XID) outboxLoop(ctx context.Context) {
+	interval := x.outbox.interval
+	if interval <= 0 {
+		interval = DefaultOutboxInterval
+	}
+
+	failures := 0
+
+	for {
+		delay := interval
+
+		if err := x.replayOutbox(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			x.outboxError(err)
+
+			delay = backoff(interval, DefaultOutboxMaxBackoff, failures)
+			failures++
+		} else {
+			failures = 0
+		}
+
+		if sleep(ctx, jitter(delay, outboxJitter)) != nil {
+			return
+		}
+	}
+}
+
+// replayOutbox sends the queued requests in order until the outbox is empty
+// or the service is still unavailable.
+func (x *XID) replayOutbox(ctx context.Context) error {
+	for {
+		entries, err := x.outbox.queue.Peek(1)
+		if err != nil || len(entries) == 0 {
+			return err
+		}
+
+		entry := entries[0]
+
+		sendErr := x.resend(ctx, entry)
+		if sendErr != nil && deferrable(sendErr) {
+			return sendErr
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err := x.outbox.queue.Remove(1); err != nil {
+			return err
+		}
+
+		x.observeOutbox()
+
+		if sendErr != nil {
+			x.outboxError(fmt.Errorf("%w: %w", ErrOutboxDropped, sendErr))
+		} else {
+			x.log().DebugContext(ctx, "ceeid outbox request delivered")
+		}
+	}
+}
+
+// resend sends a queued request again. It bypasses the shared calls of Send,
+// which would queue it a second time.
+func (x *XID) resend(ctx context.Context, entry outbox.Entry) error {
+	body, err := json.Marshal(entry.Request)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrMarshal, err)
+	}
+
+	_, err = x.postGenerate(WithIdempotencyKey(ctx, entry.Key), entry.Request, body)
+
+	return err
+}
+
+func (x *XID) outboxError(err error) {
+	x.log().Warn("ceeid outbox replay failed", slog.String(LogErrorClass, errorClass(err)))
+
+	if x.outbox.onError != nil {
+		x.outbox.onError(err)
+	}
+}
@@ -0,0 +1,249 @@
+// Package outbox provides the durable queue used by the client to defer
+// generate calls while the service is unreachable, together with a
+// file-backed implementation.
+package outbox
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ceeideu/sdk/hem"
+)
+
+const DefaultMaxLen = 100000
+
+var (
+	ErrFull  = errors.New("outbox full")
+	ErrOpen  = errors.New("outbox open error")
+	ErrWrite = errors.New("outbox write error")
+)
+
+// Entry is a queued request. Key is sent as the idempotency key when the
+// request is replayed.
+type Entry struct {
+	Key     string      `json:"key"`
+	Request hem.Request `json:"request"`
+}
+
+// Queue is a FIFO queue of entries. Implementations must be safe for
+// concurrent use.
+type Queue interface {
+	Push(e Entry) error
+	// Peek returns up to n entries from the head of the queue, without removing them.
+	Peek(n int) ([]Entry, error)
+	// Remove removes n entries from the head of the queue.
+	Remove(n int) error
+	Len() int
+}
+
+// File is a Queue persisted to a file as JSON lines. Pushes append an entry
+// and removals a marker to the file, which is rewritten atomically once most
+// of its lines are stale.
+type File struct {
+	path    string
+	maxLen  int
+	entries []Entry
+	// stale counts the lines of removed entries and markers left in the file.
+	stale int
+
+	m sync.Mutex
+}
+
+// removal is the marker appended by Remove.
+type removal struct {
+	Removed int `json:"removed"`
+}
+
+// line is a line of the file, either an entry or a removal.
+type line struct {
+	Entry
+	removal
+}
+
+// WithMaxLen bounds the number of queued entries, further pushes fail with ErrFull.
+func WithMaxLen(n int) func(*File) {
+	return func(f *File) {
+		f.maxLen = n
+	}
+}
+
+// Open loads the queue persisted at path, creating it on first push. A
+// truncated last line, left by a crash during a write, is dropped.
+func Open(path string, opts ...func(*File)) (*File, error) {
+	f := &File{path: path, maxLen: DefaultMaxLen}
+
+	for _, o := range opts {
+		o(f)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %w", ErrOpen, err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+
+	for scanner.Scan() {
+		var l line
+		if err := json.Unmarshal(scanner.Bytes(), &l); err != nil {
+			continue
+		}
+
+		if l.Removed > 0 {
+			n := min(l.Removed, len(f.entries))
+			f.entries = f.entries[n:]
+			f.stale += n + 1
+
+			continue
+		}
+
+		f.entries = append(f.entries, l.Entry)
+	}
+
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		if err := f.write(f.entries); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrOpen, err)
+		}
+
+		f.stale = 0
+	}
+
+	return f, nil
+}
+
+func (f *File) Push(e Entry) error {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if f.maxLen > 0 && len(f.entries) >= f.maxLen {
+		return ErrFull
+	}
+
+	if err := f.append(e); err != nil {
+		return err
+	}
+
+	f.entries = append(f.entries, e)
+
+	return nil
+}
+
+func (f *File) Peek(n int) ([]Entry, error) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	n = min(n, len(f.entries))
+
+	return append([]Entry(nil), f.entries[:n]...), nil
+}
+
+func (f *File) Remove(n int) error {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	n = min(n, len(f.entries))
+	if n == 0 {
+		return nil
+	}
+
+	rest := f.entries[n:]
+
+	if stale := f.stale + n + 1; stale < len(rest) {
+		if err := f.append(removal{Removed: n}); err != nil {
+			return err
+		}
+
+		f.stale = stale
+	} else {
+		if err := f.write(rest); err != nil {
+			return err
+		}
+
+		f.stale = 0
+	}
+
+	f.entries = rest
+
+	return nil
+}
+
+func (f *File) Len() int {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	return len(f.entries)
+}
+
+// append appends v as a line to the file and syncs it.
+func (f *File) append(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+
+	return nil
+}
+
+// write replaces the file with entries through a temporary file, so a crash
+// never leaves a partially written queue.
+func (f *File) write(entries []Entry) error {
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			tmp.Close()
+
+			return fmt.Errorf("%w: %w", ErrWrite, err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package outbox
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/ceeideu/sdk/hem"
+)
+
+func TestFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "outbox")
+
+	f, err := Open(path, WithMaxLen(3))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	for _, key := range []string{"a", "b", "c"} {
+		if err := f.Push(Entry{Key: key, Request: hem.FromEmail(key + "@com")}); err != nil {
+			t.Fatalf("Push() error = %v", err)
+		}
+	}
+
+	if err := f.Push(Entry{Key: "d"}); !errors.Is(err, ErrFull) {
+		t.Errorf("Push() error = %v, want %v", err, ErrFull)
+	}
+
+	if err := f.Remove(1); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+
+	// simulate a crash during a push
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	_, _ = file.WriteString(`{"key":"e","requ`)
+	file.Close()
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	if reopened.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", reopened.Len())
+	}
+
+	entries, _ := reopened.Peek(5)
+	if len(entries) != 2 || entries[0].Key != "b" || entries[1].Key != "c" {
+		t.Fatalf("Peek() = %v", entries)
+	}
+
+	if want := hem.FromEmail("b@com"); entries[0].Request.Type != want.Type || entries[0].Request.Value != want.Value {
+		t.Errorf("Peek() request = %v, want %v", entries[0].Request, want)
+	}
+
+	if err := reopened.Remove(2); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+
+	if reopened, _ = Open(path); reopened.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", reopened.Len())
+	}
+}
+
+func TestFile_Remove(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "outbox")
+	f, _ := Open(path)
+
+	for i := 0; i < 10; i++ {
+		if err := f.Push(Entry{Key: strconv.Itoa(i)}); err != nil {
+			t.Fatalf("Push() error = %v", err)
+		}
+	}
+
+	lines := func() int {
+		data, _ := os.ReadFile(path)
+
+		return bytes.Count(data, []byte("\n"))
+	}
+
+	// removals append markers instead of rewriting the file
+	for i := 0; i < 3; i++ {
+		if err := f.Remove(1); err != nil {
+			t.Fatalf("Remove() error = %v", err)
+		}
+	}
+
+	if n := lines(); n != 13 {
+		t.Errorf("lines = %d, want 13", n)
+	}
+
+	reopened, _ := Open(path)
+	if entries, _ := reopened.Peek(1); reopened.Len() != 7 || entries[0].Key != "3" {
+		t.Fatalf("Peek() = %v, Len() = %d, want 7 entries from 3", entries, reopened.Len())
+	}
+
+	// the file is compacted once most of it is stale
+	if err := reopened.Remove(1); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+
+	if n := lines(); n != 6 {
+		t.Errorf("lines = %d, want 6", n)
+	}
+
+	if reopened, _ = Open(path); reopened.Len() != 6 {
+		t.Errorf("Len() = %d, want 6", reopened.Len())
+	}
+}
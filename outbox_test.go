@@ -0,0 +1,222 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ceeideu/sdk/hem"
+	"github.com/ceeideu/sdk/outbox"
+	"github.com/ceeideu/sdk/properties"
+	"github.com/ceeideu/sdk/xid"
+)
+
+func TestWithOutbox(t *testing.T) {
+	t.Parallel()
+
+	var (
+		up       atomic.Bool
+		m        sync.Mutex
+		received []string
+		keys     []string
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req hem.Request
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		switch {
+		case r.URL.Path == KeysRefresh:
+			w.WriteHeader(http.StatusNotFound)
+		case req.Value == hem.FromEmail("bad@com").Value:
+			w.WriteHeader(http.StatusBadRequest)
+		case !up.Load():
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			m.Lock()
+			received = append(received, req.Value)
+			keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+			m.Unlock()
+
+			status := xid.Okay
+			if req.Value == hem.FromEmail("blocked@com").Value {
+				status = xid.UserBlocked
+			}
+
+			m, _ := json.Marshal(xid.Response{Value: "xid", Status: status})
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(m)
+		}
+	}))
+	defer ts.Close()
+
+	q, _ := outbox.Open(filepath.Join(t.TempDir(), "outbox"))
+
+	var dropped atomic.Int32
+	xidClient, _ := NewXID(ts.URL, XApiMockValue, WithHTTPClient(ts.Client()),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+		WithOutbox(q), WithOutboxInterval(5*time.Millisecond),
+		WithOutboxErrorHandler(func(err error) {
+			if errors.Is(err, ErrOutboxDropped) {
+				dropped.Add(1)
+			}
+		}))
+
+	ctx := WithIdempotencyKey(context.Background(), "login-1")
+	if _, err := xidClient.Send(ctx, hem.FromEmail("a@com")); !errors.Is(err, ErrQueued) || !errors.Is(err, ErrStatusNotOK) {
+		t.Errorf("XID.Send() error = %v, want %v", err, ErrQueued)
+	}
+
+	if _, err := xidClient.Send(context.Background(), hem.FromEmail("blocked@com")); !errors.Is(err, ErrQueued) {
+		t.Errorf("XID.Send() error = %v, want %v", err, ErrQueued)
+	}
+
+	if _, err := xidClient.Send(context.Background(), hem.FromEmail("bad@com")); errors.Is(err, ErrQueued) || err == nil {
+		t.Errorf("XID.Send() error = %v, want a not queued error", err)
+	}
+
+	if n := xidClient.OutboxLen(); n != 2 {
+		t.Fatalf("XID.OutboxLen() = %d, want 2", n)
+	}
+
+	_ = xidClient.Start(context.Background())
+	defer xidClient.Close()
+
+	time.Sleep(20 * time.Millisecond)
+
+	if n := xidClient.OutboxLen(); n != 2 {
+		t.Fatalf("XID.OutboxLen() = %d while the service is down, want 2", n)
+	}
+
+	up.Store(true)
+	waitFor(t, func() bool { return xidClient.OutboxLen() == 0 })
+
+	m.Lock()
+	defer m.Unlock()
+
+	if len(received) != 2 || received[0] != hem.FromEmail("a@com").Value || received[1] != hem.FromEmail("blocked@com").Value {
+		t.Errorf("received %v", received)
+	}
+
+	if len(keys) != 2 || keys[0] != "login-1" || keys[1] == "" {
+		t.Errorf("idempotency keys %v", keys)
+	}
+
+	if n := dropped.Load(); n != 1 {
+		t.Errorf("dropped = %d, want 1", n)
+	}
+}
+
+func Test_deferrable(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "communication", err: ErrCommunication, want: true},
+		{name: "circuit open", err: ErrCircuitOpen, want: true},
+		{name: "timeout", err: context.DeadlineExceeded, want: true},
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "unavailable", err: &APIError{StatusCode: http.StatusServiceUnavailable}, want: true},
+		{name: "too many requests", err: &APIError{StatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "bad request", err: &APIError{StatusCode: http.StatusBadRequest}, want: false},
+		{name: "consent", err: ErrConsent, want: false},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if got := deferrable(test.err); got != test.want {
+				t.Errorf("deferrable() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestWithOutbox_PersonalData(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "outbox")
+	q, _ := outbox.Open(path)
+
+	xidClient, _ := NewXID(ts.URL, XApiMockValue, WithHTTPClient(ts.Client()),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}), WithOutbox(q))
+
+	hemReq := hem.FromEmail("a@com").WithProperties(properties.WithConsent("TCF").WithIP("1.2.3.4").WithUserAgent("ua"))
+	if _, err := xidClient.Send(context.Background(), hemReq); !errors.Is(err, ErrQueued) {
+		t.Fatalf("XID.Send() error = %v, want %v", err, ErrQueued)
+	}
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "1.2.3.4") || strings.Contains(string(data), `"ua"`) {
+		t.Errorf("outbox file holds personal data: %s", data)
+	}
+
+	entries, _ := q.Peek(1)
+	if len(entries) != 1 || len(entries[0].Request.Properties) != 1 || entries[0].Request.Properties[properties.Consent] != "TCF" {
+		t.Errorf("queued entries = %v, want the consent only", entries)
+	}
+}
+
+func TestWithOutbox_Coalesced(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	q, _ := outbox.Open(filepath.Join(t.TempDir(), "outbox"))
+
+	xidClient, _ := NewXID(ts.URL, XApiMockValue, WithHTTPClient(ts.Client()),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}), WithOutbox(q))
+
+	const n = 5
+
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = xidClient.Send(context.Background(), hem.FromEmail("a@com"))
+		}(i)
+	}
+
+	waitFor(t, func() bool { return xidClient.flights.generate.waiting() == n })
+	close(release)
+	wg.Wait()
+
+	for i, err := range errs {
+		if !errors.Is(err, ErrQueued) {
+			t.Errorf("caller %d: XID.Send() error = %v, want %v", i, err, ErrQueued)
+		}
+	}
+
+	if h := hits.Load(); h != 1 {
+		t.Errorf("server hits = %d, want 1", h)
+	}
+
+	if l := xidClient.OutboxLen(); l != 1 {
+		t.Errorf("XID.OutboxLen() = %d, want 1", l)
+	}
+}
@@ -1,5 +1,10 @@
 package properties
 
+import (
+	"log/slog"
+	"sort"
+)
+
 type Value map[string]string
 
 const (
@@ -11,6 +16,8 @@ const (
 	Referer = "referer"
 	// The IP address of the user. This property is optional and can be included for additional context or tracking purposes.
 	IPAddress = "ip"
+
+	Redacted = "[REDACTED]"
 )
 
 func WithConsent(consent string) Value {
@@ -37,3 +44,42 @@ func (v Value) WithIP(ip string) Value {
 
 	return v
 }
+
+// WithoutPersonalData returns a copy of v holding only the consent, every
+// other property may carry personal data.
+func (v Value) WithoutPersonalData() Value {
+	if v == nil {
+		return nil
+	}
+
+	stripped := make(Value, 1)
+	if consent, ok := v[Consent]; ok {
+		stripped[Consent] = consent
+	}
+
+	return stripped
+}
+
+// LogValue implements slog.LogValuer. Only the consent is logged as is, every
+// other property may carry personal data and is redacted.
+func (v Value) LogValue() slog.Value {
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, k := range keys {
+		if k == Consent {
+			attrs = append(attrs, slog.String(k, v[k]))
+
+			continue
+		}
+
+		attrs = append(attrs, slog.String(k, Redacted))
+	}
+
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,66 @@
+package properties
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+func TestValue_LogValue(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		v    Value
+		want string
+	}{
+		{
+			name: "empty",
+			v:    Value{},
+			want: "level=INFO msg=m\n",
+		},
+		{
+			name: "redacted",
+			v:    WithConsent("TCF").WithIP("1.2.3.4").WithUserAgent("ua").WithReferer("ref"),
+			want: "level=INFO msg=m p.consent=TCF p.ip=[REDACTED] p.referer=[REDACTED] p.user-agent=[REDACTED]\n",
+		},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+				ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+					if a.Key == slog.TimeKey {
+						return slog.Attr{}
+					}
+
+					return a
+				},
+			}))
+			logger.Info("m", slog.Any("p", test.v))
+			if got := buf.String(); got != test.want {
+				t.Errorf("LogValue() logged %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestValue_WithoutPersonalData(t *testing.T) {
+	t.Parallel()
+
+	v := WithConsent("TCF").WithIP("1.2.3.4").WithUserAgent("ua").WithReferer("ref")
+
+	got := v.WithoutPersonalData()
+	if len(got) != 1 || got[Consent] != "TCF" {
+		t.Errorf("WithoutPersonalData() = %v, want the consent only", got)
+	}
+
+	if len(v) != 4 {
+		t.Errorf("WithoutPersonalData() modified the value: %v", v)
+	}
+
+	if got := Value(nil).WithoutPersonalData(); got != nil {
+		t.Errorf("WithoutPersonalData() = %v, want nil", got)
+	}
+}
@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultRefreshInterval   = time.Second
+	DefaultRefreshMaxBackoff = time.Minute
+
+	refreshJitter = 0.1
+)
+
+var ErrAlreadyStarted = errors.New("already started")
+
+// WithAutoRefresh makes NewXID start a background loop calling Refresh every
+// interval. The loop is stopped by Close.
+func WithAutoRefresh(interval time.Duration) func(*XID) {
+	return func(x *XID) {
+		x.refresher.interval = interval
+		x.refresher.auto = true
+	}
+}
+
+// WithRefreshMaxBackoff caps the delay between retries of a failing background refresh.
+func WithRefreshMaxBackoff(d time.Duration) func(*XID) {
+	return func(x *XID) {
+		x.refresher.maxBackoff = d
+	}
+}
+
+// WithRefreshErrorHandler sets the callback receiving background refresh errors.
+func WithRefreshErrorHandler(fn func(error)) func(*XID) {
+	return func(x *XID) {
+		x.refresher.onError = fn
+	}
+}
+
+type refresher struct {
+	interval   time.Duration
+	maxBackoff time.Duration
+	auto       bool
+	onError    func(error)
+}
+
+type lifecycle struct {
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	m sync.Mutex
+}
+
+// Start runs the background loops of the client until ctx is done or Close
+// is called.
+func (x *XID) Start(ctx context.Context) error {
+	x.lifecycle.m.Lock()
+	defer x.lifecycle.m.Unlock()
+
+	if x.lifecycle.cancel != nil {
+		return ErrAlreadyStarted
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	x.lifecycle.cancel = cancel
+
+	x.lifecycle.wg.Add(1)
+
+	go func() {
+		defer x.lifecycle.wg.Done()
+		x.refreshLoop(ctx)
+	}()
+
+	if x.outbox.queue != nil {
+		x.lifecycle.wg.Add(1)
+
+		go func() {
+			defer x.lifecycle.wg.Done()
+			x.outboxLoop(ctx)
+		}()
+	}
+
+	if len(x.endpoints) > 1 {
+		x.lifecycle.wg.Add(1)
+
+		go func() {
+			defer x.lifecycle.wg.Done()
+			x.probeLoop(ctx)
+		}()
+	}
+
+	return nil
+}
+
+// Close stops the background loops started by Start and waits for them to exit.
+func (x *XID) Close() error {
+	x.lifecycle.m.Lock()
+	defer x.lifecycle.m.Unlock()
+
+	if x.lifecycle.cancel == nil {
+		return nil
+	}
+
+	x.lifecycle.cancel()
+	x.lifecycle.wg.Wait()
+	x.lifecycle.cancel = nil
+
+	return nil
+}
+
+func (x *XID) refreshLoop(ctx context.Context) {
+	interval := x.refresher.interval
+	if interval <= 0 {
+		interval = DefaultRefreshInterval
+	}
+
+	maxBackoff := x.refresher.maxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultRefreshMaxBackoff
+	}
+
+	failures := 0
+
+	for {
+		delay := interval
+
+		if err := x.Refresh(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			if x.refresher.onError != nil {
+				x.refresher.onError(err)
+			}
+
+			delay = backoff(interval, maxBackoff, failures)
+			failures++
+		} else {
+			failures = 0
+		}
+
+		if sleep(ctx, jitter(delay, refreshJitter)) != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testKey = "be2c553d9ea747446fe610c12aab4880027eca7378da8ff9eff3bf1631b3334b"
+
+func TestXID_Start(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		statusCode int
+		wantErr    bool
+	}{
+		{
+			name:       "ok",
+			statusCode: http.StatusOK,
+			wantErr:    false,
+		},
+		{
+			name:       "refresh error",
+			statusCode: http.StatusInternalServerError,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var hits atomic.Int32
+			testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, r *http.Request) {
+				hits.Add(1)
+				writer.Header().Set("Content-Type", "application/json")
+				writer.WriteHeader(test.statusCode)
+				m, _ := json.Marshal(KeysResp{Encryption: Encryption{ID: 1, Value: testKey}})
+				_, _ = writer.Write(m)
+			}))
+			defer testServer.Close()
+
+			errs := make(chan error, 10)
+			xidClient, err := NewXID(testServer.URL, "foo",
+				WithHTTPClient(testServer.Client()),
+				WithAutoRefresh(time.Millisecond),
+				WithRefreshMaxBackoff(2*time.Millisecond),
+				WithRefreshErrorHandler(func(err error) {
+					select {
+					case errs <- err:
+					default:
+					}
+				}),
+			)
+			if err != nil {
+				t.Fatalf("NewXID() error = %v", err)
+			}
+
+			if err := xidClient.Start(context.Background()); !errors.Is(err, ErrAlreadyStarted) {
+				t.Errorf("Start() error = %v, want %v", err, ErrAlreadyStarted)
+			}
+
+			deadline := time.Now().Add(time.Second)
+			for hits.Load() < 3 && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+
+			if err := xidClient.Close(); err != nil {
+				t.Errorf("Close() error = %v", err)
+			}
+
+			if hits.Load() < 3 {
+				t.Errorf("hits = %d, want at least 3", hits.Load())
+			}
+
+			time.Sleep(10 * time.Millisecond)
+			stopped := hits.Load()
+			time.Sleep(10 * time.Millisecond)
+			if hits.Load() != stopped {
+				t.Errorf("refresh loop still running after Close()")
+			}
+
+			if gotErr := len(errs) > 0; gotErr != test.wantErr {
+				t.Errorf("error reported = %v, wantErr %v", gotErr, test.wantErr)
+			}
+		})
+	}
+}
+
+func Test_backoff(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		base     time.Duration
+		maxDelay time.Duration
+		attempt  int
+		want     time.Duration
+	}{
+		{name: "first", base: time.Second, maxDelay: time.Minute, attempt: 0, want: time.Second},
+		{name: "third", base: time.Second, maxDelay: time.Minute, attempt: 2, want: 4 * time.Second},
+		{name: "capped", base: time.Second, maxDelay: 5 * time.Second, attempt: 10, want: 5 * time.Second},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if got := backoff(test.base, test.maxDelay, test.attempt); got != test.want {
+				t.Errorf("backoff() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
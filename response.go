@@ -0,0 +1,112 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/ceeideu/sdk/xid"
+)
+
+const (
+	DefaultMaxResponseSize = 1 << 20
+
+	maxDrainSize = 64 << 10
+)
+
+var (
+	ErrResponseTooLarge = errors.New("response too large")
+	ErrContentType      = errors.New("unexpected content type")
+	ErrInvalidResponse  = errors.New("invalid response")
+)
+
+// WithMaxResponseSize limits the size of the response bodies decoded by the
+// client, DefaultMaxResponseSize by default.
+func WithMaxResponseSize(n int64) func(*XID) {
+	return func(x *XID) {
+		x.maxResponseSize = n
+	}
+}
+
+// decodeResponse decodes the JSON body of resp into v and validates it.
+// Decoding errors wrap ErrDecode, missing required fields ErrInvalidResponse.
+func (x *XID) decodeResponse(resp *http.Response, v any) error {
+	if err := checkContentType(resp.Header.Get("Content-Type")); err != nil {
+		return fmt.Errorf("%w: %w", ErrDecode, err)
+	}
+
+	limit := x.maxResponseSize
+	if limit <= 0 {
+		limit = DefaultMaxResponseSize
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrDecode, err)
+	}
+
+	if int64(len(body)) > limit {
+		return fmt.Errorf("%w: %w: over %d bytes", ErrDecode, ErrResponseTooLarge, limit)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("%w: %w", ErrDecode, err)
+	}
+
+	return validate(v)
+}
+
+// checkContentType accepts JSON bodies only.
+func checkContentType(contentType string) error {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrContentType, contentType)
+	}
+
+	if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrContentType, mediaType)
+}
+
+// validate checks the fields required in the responses of the service.
+func validate(v any) error {
+	switch r := v.(type) {
+	case *xid.Response:
+		return requireXID(r.Value, r.StatusOf())
+	case *xid.RefreshResp:
+		return requireXID(r.Value, r.StatusOf())
+	case *xid.TokenResponse:
+		return require(r.Value != "", "token value")
+	case *xid.TokenRefreshResp:
+		return require(r.Token != "", "token")
+	case *xid.DecodeResp:
+		return require(r.Value != "", "decoded value")
+	case *KeysResp:
+		return require(r.Encryption.Value != "", "encryption key")
+	default:
+		return nil
+	}
+}
+
+// requireXID requires the xID, unless the status tells why there is none.
+func requireXID(value string, status xid.StatusOf) error {
+	if status == xid.StatusOfUserBlocked || status == xid.StatusOfInvalidConsent {
+		return nil
+	}
+
+	return require(value != "", "xid value")
+}
+
+func require(present bool, field string) error {
+	if !present {
+		return fmt.Errorf("%w: missing %s", ErrInvalidResponse, field)
+	}
+
+	return nil
+}
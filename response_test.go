@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ceeideu/sdk/hem"
+	"github.com/ceeideu/sdk/xid"
+)
+
+func TestXID_StrictResponse(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantErr     []error
+	}{
+		{name: "ok", contentType: "application/json", body: `{"value":"xid","status":"ok"}`},
+		{name: "problem json", contentType: "application/problem+json; charset=utf-8", body: `{"value":"xid"}`},
+		{name: "no content type", body: `{"value":"xid"}`, wantErr: []error{ErrDecode, ErrContentType}},
+		{name: "text", contentType: "text/plain; charset=utf-8", body: `{"value":"xid"}`, wantErr: []error{ErrDecode, ErrContentType}},
+		{name: "html", contentType: "text/html", body: `<html>{"value":"xid"}</html>`, wantErr: []error{ErrDecode, ErrContentType}},
+		{
+			name:        "too large",
+			contentType: "application/json",
+			body:        `{"value":"` + strings.Repeat("x", 100) + `"}`,
+			wantErr:     []error{ErrDecode, ErrResponseTooLarge},
+		},
+		{name: "trailing data", contentType: "application/json", body: `{"value":"xid"}<html>`, wantErr: []error{ErrDecode}},
+		{name: "missing value", contentType: "application/json", body: `{"status":"ok"}`, wantErr: []error{ErrInvalidResponse}},
+		{
+			name:        "blocked without value",
+			contentType: "application/json",
+			body:        `{"status":"` + xid.UserBlocked + `"}`,
+			wantErr:     []error{ErrUserBlocked},
+		},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", test.contentType)
+				_, _ = w.Write([]byte(test.body))
+			}))
+			defer ts.Close()
+
+			xidClient, _ := NewXID(ts.URL, XApiMockValue, WithHTTPClient(ts.Client()), WithMaxResponseSize(64))
+
+			_, err := xidClient.Send(context.Background(), hem.FromEmail("test@com"))
+			if (err != nil) != (len(test.wantErr) > 0) {
+				t.Fatalf("XID.Send() error = %v, wantErr %v", err, test.wantErr)
+			}
+			for _, want := range test.wantErr {
+				if !errors.Is(err, want) {
+					t.Errorf("XID.Send() error = %v, want %v", err, want)
+				}
+			}
+			if errors.Is(err, ErrInvalidResponse) && errors.Is(err, ErrDecode) {
+				t.Errorf("XID.Send() error = %v, validation errors are not decode errors", err)
+			}
+		})
+	}
+}
+
+func TestXID_GetKeysValidation(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"Decryption":{"1":"` + testKey + `"}}`))
+	}))
+	defer ts.Close()
+
+	xidClient, _ := NewXID(ts.URL, XApiMockValue, WithHTTPClient(ts.Client()))
+
+	if _, err := xidClient.GetKeys(context.Background()); !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("XID.GetKeys() error = %v, want %v", err, ErrInvalidResponse)
+	}
+
+	if err := xidClient.Refresh(context.Background()); !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("XID.Refresh() error = %v, want %v", err, ErrInvalidResponse)
+	}
+}
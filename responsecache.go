@@ -0,0 +1,78 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/ceeideu/sdk/cache"
+	"github.com/ceeideu/sdk/hem"
+	"github.com/ceeideu/sdk/metrics"
+	"github.com/ceeideu/sdk/properties"
+	"github.com/ceeideu/sdk/xid"
+)
+
+const (
+	DefaultCacheTTL = time.Hour
+
+	cacheHit  = "hit"
+	cacheMiss = "miss"
+)
+
+// WithCache makes Send return responses cached in backend for ttl, so only
+// cache misses hit the network. Responses are keyed on the HEM type and value
+// and the consent, and only OK responses are cached.
+func WithCache(backend cache.Backend, ttl time.Duration) func(*XID) {
+	return func(x *XID) {
+		x.cache.backend = backend
+		x.cache.ttl = ttl
+	}
+}
+
+type responseCache struct {
+	backend cache.Backend
+	ttl     time.Duration
+}
+
+func (x *XID) cachedResponse(hemReq hem.Request) (xid.Response, bool) {
+	if x.cache.backend == nil {
+		return xid.Response{}, false
+	}
+
+	resp, ok := x.cache.backend.Get(cacheKey(hemReq))
+
+	result := cacheMiss
+	if ok {
+		result = cacheHit
+	}
+
+	x.meter().IncCounter(metrics.CacheRequestsTotal, metrics.Label{Name: metrics.LabelResult, Value: result})
+
+	return resp, ok
+}
+
+func (x *XID) cacheResponse(hemReq hem.Request, resp xid.Response) {
+	if x.cache.backend == nil || resp.StatusOf() != xid.StatusOfOK {
+		return
+	}
+
+	ttl := x.cache.ttl
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+
+	x.cache.backend.Set(cacheKey(hemReq), resp, ttl)
+}
+
+// cacheKey hashes the fields the response depends on, so no HEM is held as
+// is by the backend.
+func cacheKey(hemReq hem.Request) string {
+	h := sha256.New()
+
+	for _, s := range []string{hemReq.Type, hemReq.Value, hemReq.Properties[properties.Consent]} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ceeideu/sdk/cache"
+	"github.com/ceeideu/sdk/hem"
+	"github.com/ceeideu/sdk/properties"
+	"github.com/ceeideu/sdk/xid"
+)
+
+func TestWithCache(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+
+		var req hem.Request
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		status := xid.Okay
+		if req.Properties[properties.Consent] == "none" {
+			status = xid.InvalidConsent
+		}
+
+		m, _ := json.Marshal(xid.Response{Value: "xid-" + req.Value, Status: status})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(m)
+	}))
+	defer ts.Close()
+
+	backend := cache.NewLRU(10)
+	xidClient, _ := NewXID(ts.URL, XApiMockValue, WithHTTPClient(ts.Client()), WithCache(backend, time.Minute))
+
+	tests := []struct {
+		name     string
+		req      hem.Request
+		wantHits int32
+	}{
+		{name: "miss", req: hem.FromEmail("a@com").WithProperties(properties.WithConsent("TCF")), wantHits: 1},
+		{name: "hit", req: hem.FromEmail("a@com").WithProperties(properties.WithConsent("TCF").WithIP("1.2.3.4")), wantHits: 1},
+		{name: "other consent", req: hem.FromEmail("a@com").WithProperties(properties.WithConsent("TCF2")), wantHits: 2},
+		{name: "other value", req: hem.FromEmail("b@com").WithProperties(properties.WithConsent("TCF")), wantHits: 3},
+		{name: "not ok", req: hem.FromEmail("a@com").WithProperties(properties.WithConsent("none")), wantHits: 4},
+		{name: "not ok not cached", req: hem.FromEmail("a@com").WithProperties(properties.WithConsent("none")), wantHits: 5},
+	}
+	for _, test := range tests {
+		got, _ := xidClient.Send(context.Background(), test.req)
+		if got.Value != "xid-"+test.req.Value {
+			t.Errorf("%s: XID.Send() = %v", test.name, got)
+		}
+		if n := hits.Load(); n != test.wantHits {
+			t.Errorf("%s: server hits = %d, want %d", test.name, n, test.wantHits)
+		}
+	}
+
+	if backend.Len() != 3 {
+		t.Errorf("cache len = %d, want 3", backend.Len())
+	}
+}
@@ -0,0 +1,180 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	RetryAfterHeader     = "Retry-After"
+
+	defaultRetryAttempts    = 3
+	defaultRetryBaseDelay   = 100 * time.Millisecond
+	defaultRetryMaxDelay    = 2 * time.Second
+	defaultRetryJitter      = 0.2
+	defaultRetryBudgetRatio = 0.1
+	defaultRetryBudgetBurst = 10
+)
+
+// RetryPolicy configures how DoHTTPReq retries transport errors, 5xx and 429
+// responses. Requests with non-idempotent methods are only retried when an
+// idempotency key is attached to the context with WithIdempotencyKey.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, doubled on every next one.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff delay. A Retry-After longer than MaxDelay ends retrying.
+	MaxDelay time.Duration
+	// Jitter spreads every delay by the given fraction.
+	Jitter float64
+	// BudgetRatio is the number of retries earned by each request; zero disables the budget.
+	BudgetRatio float64
+	// BudgetBurst is the maximum number of retries the budget can hold.
+	BudgetBurst int
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: defaultRetryAttempts,
+		BaseDelay:   defaultRetryBaseDelay,
+		MaxDelay:    defaultRetryMaxDelay,
+		Jitter:      defaultRetryJitter,
+		BudgetRatio: defaultRetryBudgetRatio,
+		BudgetBurst: defaultRetryBudgetBurst,
+	}
+}
+
+func WithRetryPolicy(p RetryPolicy) func(*XID) {
+	return func(x *XID) {
+		x.retry = p
+		x.retryBudget = newRetryBudget(p.BudgetRatio, p.BudgetBurst)
+	}
+}
+
+type idempotencyKey struct{}
+
+// WithIdempotencyKey attaches an idempotency key sent with requests made with
+// ctx, allowing retries of non-idempotent calls.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+func idempotencyKeyFrom(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKey{}).(string)
+
+	return key
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func retryableResponse(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// retryAfter parses the Retry-After header given either in seconds or as an HTTP date.
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+
+	value := resp.Header.Get(RetryAfterHeader)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if d := date.Sub(now); d > 0 {
+			return d, true
+		}
+
+		return 0, true
+	}
+
+	return 0, false
+}
+
+func (p RetryPolicy) delay(resp *http.Response, attempt int, now time.Time) (time.Duration, bool) {
+	if d, ok := retryAfter(resp, now); ok {
+		return d, p.MaxDelay <= 0 || d <= p.MaxDelay
+	}
+
+	return jitter(backoff(p.BaseDelay, p.MaxDelay, attempt), p.Jitter), true
+}
+
+// retryBudget limits retries to a fraction of the requests, so retries
+// cannot multiply the load on an already failing service.
+type retryBudget struct {
+	ratio  float64
+	burst  float64
+	tokens float64
+
+	m sync.Mutex
+}
+
+func newRetryBudget(ratio float64, burst int) *retryBudget {
+	if ratio <= 0 {
+		return nil
+	}
+
+	return &retryBudget{ratio: ratio, burst: float64(burst), tokens: float64(burst)}
+}
+
+func (b *retryBudget) deposit() {
+	if b == nil {
+		return
+	}
+
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	b.tokens = min(b.tokens+b.ratio, b.burst)
+}
+
+func (b *retryBudget) withdraw() bool {
+	if b == nil {
+		return true
+	}
+
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+
+	return true
+}
+
+// drain discards the rest of the body, up to maxDrainSize, so the connection
+// can be reused, and closes it.
+func drain(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+
+	_, _ = io.CopyN(io.Discard, resp.Body, maxDrainSize)
+	resp.Body.Close()
+}
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestXID_DoHTTPReqRetry(t *testing.T) {
+	t.Parallel()
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	tests := []struct {
+		name        string
+		method      string
+		idemKey     string
+		policy      RetryPolicy
+		failures    int32
+		failStatus  int
+		retryAfter  string
+		wantErr     bool
+		wantAttempt int32
+	}{
+		{
+			name:        "no policy",
+			method:      http.MethodGet,
+			failures:    1,
+			failStatus:  http.StatusServiceUnavailable,
+			wantErr:     true,
+			wantAttempt: 1,
+		},
+		{
+			name:        "get retried",
+			method:      http.MethodGet,
+			policy:      policy,
+			failures:    2,
+			failStatus:  http.StatusServiceUnavailable,
+			wantErr:     false,
+			wantAttempt: 3,
+		},
+		{
+			name:        "attempts exhausted",
+			method:      http.MethodGet,
+			policy:      policy,
+			failures:    3,
+			failStatus:  http.StatusInternalServerError,
+			wantErr:     true,
+			wantAttempt: 3,
+		},
+		{
+			name:        "too many requests",
+			method:      http.MethodGet,
+			policy:      policy,
+			failures:    1,
+			failStatus:  http.StatusTooManyRequests,
+			retryAfter:  "0",
+			wantErr:     false,
+			wantAttempt: 2,
+		},
+		{
+			name:        "retry after too long",
+			method:      http.MethodGet,
+			policy:      policy,
+			failures:    1,
+			failStatus:  http.StatusTooManyRequests,
+			retryAfter:  "60",
+			wantErr:     true,
+			wantAttempt: 1,
+		},
+		{
+			name:        "client error not retried",
+			method:      http.MethodGet,
+			policy:      policy,
+			failures:    1,
+			failStatus:  http.StatusBadRequest,
+			wantErr:     true,
+			wantAttempt: 1,
+		},
+		{
+			name:        "post not retried",
+			method:      http.MethodPost,
+			policy:      policy,
+			failures:    1,
+			failStatus:  http.StatusServiceUnavailable,
+			wantErr:     true,
+			wantAttempt: 1,
+		},
+		{
+			name:        "post with idempotency key",
+			method:      http.MethodPost,
+			idemKey:     "key",
+			policy:      policy,
+			failures:    1,
+			failStatus:  http.StatusServiceUnavailable,
+			wantErr:     false,
+			wantAttempt: 2,
+		},
+		{
+			name:   "budget exhausted",
+			method: http.MethodGet,
+			policy: RetryPolicy{
+				MaxAttempts: 3, BaseDelay: time.Millisecond, BudgetRatio: 0.1, BudgetBurst: 1,
+			},
+			failures:    2,
+			failStatus:  http.StatusServiceUnavailable,
+			wantErr:     true,
+			wantAttempt: 2,
+		},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var attempts atomic.Int32
+			testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, r *http.Request) {
+				if r.Header.Get(IdempotencyKeyHeader) != test.idemKey {
+					t.Errorf("%s = %q, want %q", IdempotencyKeyHeader, r.Header.Get(IdempotencyKeyHeader), test.idemKey)
+				}
+				if attempts.Add(1) <= test.failures {
+					writer.Header().Set(RetryAfterHeader, test.retryAfter)
+					writer.WriteHeader(test.failStatus)
+				}
+			}))
+			defer testServer.Close()
+
+			xidClient, _ := NewXID(testServer.URL, "foo", WithHTTPClient(testServer.Client()), WithRetryPolicy(test.policy))
+
+			ctx := context.Background()
+			if test.idemKey != "" {
+				ctx = WithIdempotencyKey(ctx, test.idemKey)
+			}
+
+			resp, err := xidClient.DoHTTPReq(ctx, test.method, testServer.URL, []byte("{}"))
+			if (err != nil) != test.wantErr {
+				t.Errorf("DoHTTPReq() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if resp != nil {
+				resp.Body.Close()
+			}
+			if attempts.Load() != test.wantAttempt {
+				t.Errorf("attempts = %d, want %d", attempts.Load(), test.wantAttempt)
+			}
+		})
+	}
+}
+
+func Test_retryAfter(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "missing", value: "", want: 0, wantOK: false},
+		{name: "seconds", value: "5", want: 5 * time.Second, wantOK: true},
+		{name: "date", value: now.Add(time.Minute).Format(http.TimeFormat), want: time.Minute, wantOK: true},
+		{name: "past date", value: now.Add(-time.Minute).Format(http.TimeFormat), want: 0, wantOK: true},
+		{name: "invalid", value: "soon", want: 0, wantOK: false},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			resp := &http.Response{Header: http.Header{}}
+			resp.Header.Set(RetryAfterHeader, test.value)
+			got, ok := retryAfter(resp, now)
+			if got != test.want || ok != test.wantOK {
+				t.Errorf("retryAfter() = %v, %v, want %v, %v", got, ok, test.want, test.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/ceeideu/sdk/crypto"
+)
+
+// WithKeySnapshot makes NewXID load the keys saved in store, so tokens can be
+// decrypted before the first refresh, and Refresh save every new set of keys.
+func WithKeySnapshot(store crypto.SnapshotStore) func(*XID) {
+	return func(x *XID) {
+		x.snapshots = store
+	}
+}
+
+// loadSnapshot applies the saved keys. They are not considered fresh, so the
+// next refresh still fetches the current keys.
+func (x *XID) loadSnapshot() {
+	if x.snapshots == nil {
+		return
+	}
+
+	keys, err := x.snapshots.Load()
+	if errors.Is(err, crypto.ErrNoSnapshot) {
+		return
+	}
+
+	if err == nil {
+		err = x.cryptoService.KeysRefresh(keys)
+	}
+
+	if err != nil {
+		x.log().Warn("ceeid keys snapshot load failed", slog.String(LogError, err.Error()))
+
+		return
+	}
+
+	x.keys.set(keysCache{keyID: keys.Encryption.ID, hasKeys: true})
+	x.log().Info("ceeid keys snapshot loaded", slog.Int(LogKeyID, int(keys.Encryption.ID)))
+}
+
+func (x *XID) saveSnapshot(ctx context.Context, keys crypto.Keys) {
+	if x.snapshots == nil {
+		return
+	}
+
+	if err := x.snapshots.Save(keys); err != nil {
+		x.log().WarnContext(ctx, "ceeid keys snapshot save failed", slog.String(LogError, err.Error()))
+	}
+}
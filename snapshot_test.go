@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ceeideu/sdk/crypto"
+)
+
+func TestWithKeySnapshot(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		m, _ := json.Marshal(KeysResp{
+			Decryption: map[uint8]string{7: testKey},
+			Encryption: Encryption{ID: 7, Value: testKey},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(m)
+	}))
+	defer ts.Close()
+
+	store, _ := crypto.NewFileSnapshotStore(filepath.Join(t.TempDir(), "keys"), strings.Repeat("ab", 32))
+
+	first, _ := NewXID(ts.URL, XApiMockValue, WithHTTPClient(ts.Client()), WithKeySnapshot(store))
+	if err := first.Refresh(context.Background()); err != nil {
+		t.Fatalf("XID.Refresh() error = %v", err)
+	}
+
+	token, err := first.TokenFromXID("xid")
+	if err != nil {
+		t.Fatalf("XID.TokenFromXID() error = %v", err)
+	}
+
+	// a restarted client decrypts before its first refresh
+	restarted, _ := NewXID(ts.URL, XApiMockValue, WithHTTPClient(ts.Client()), WithKeySnapshot(store))
+
+	got, err := restarted.DecryptToken(token)
+	if err != nil || got != "xid" {
+		t.Errorf("XID.DecryptToken() = %v, %v, want xid", got, err)
+	}
+
+	if h := hits.Load(); h != 1 {
+		t.Errorf("server hits = %d, want 1", h)
+	}
+
+	// the snapshot does not make the keys fresh
+	if err := restarted.Refresh(context.Background()); err != nil {
+		t.Fatalf("XID.Refresh() error = %v", err)
+	}
+
+	if h := hits.Load(); h != 2 {
+		t.Errorf("server hits = %d, want 2", h)
+	}
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultGenerateTimeout = 5 * time.Second
+	defaultRefreshTimeout  = 5 * time.Second
+	defaultKeysTimeout     = 10 * time.Second
+	defaultTimeout         = 10 * time.Second
+
+	defaultDialTimeout         = 5 * time.Second
+	defaultKeepAlive           = 30 * time.Second
+	defaultMaxIdleConns        = 100
+	defaultIdleConnTimeout     = 90 * time.Second
+	defaultTLSHandshakeTimeout = 5 * time.Second
+	defaultExpectContinue      = time.Second
+)
+
+// Timeouts are the timeouts of the operations, applied when the context of a
+// call has no deadline. They cover all attempts of a call, including the
+// reading of the response. A zero timeout disables it.
+type Timeouts struct {
+	// Generate applies to Send and IssueToken.
+	Generate time.Duration
+	// Refresh applies to RefreshXID and RefreshToken.
+	Refresh time.Duration
+	// Keys applies to GetKeys and Refresh.
+	Keys time.Duration
+	// Default applies to the other operations and to the health probes.
+	Default time.Duration
+}
+
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		Generate: defaultGenerateTimeout,
+		Refresh:  defaultRefreshTimeout,
+		Keys:     defaultKeysTimeout,
+		Default:  defaultTimeout,
+	}
+}
+
+// WithTimeouts replaces the DefaultTimeouts.
+func WithTimeouts(t Timeouts) func(*XID) {
+	return func(x *XID) {
+		x.timeouts = t
+	}
+}
+
+func (t Timeouts) of(op string) time.Duration {
+	switch op {
+	case OpGenerate, OpToken:
+		return t.Generate
+	case OpRefresh, OpTokenRefresh:
+		return t.Refresh
+	case OpKeys:
+		return t.Keys
+	default:
+		return t.Default
+	}
+}
+
+// withTimeout applies the timeout of the operation of ctx, unless ctx has a
+// deadline already. A context detached from a caller with a later deadline
+// runs until that deadline instead.
+func (x *XID) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+
+	deadline, _ := ctx.Value(callerDeadlineKey{}).(time.Time)
+	if d := x.timeouts.of(OperationFromContext(ctx)); d > 0 {
+		if opDeadline := time.Now().Add(d); opDeadline.After(deadline) {
+			deadline = opDeadline
+		}
+	}
+
+	if deadline.IsZero() {
+		return ctx, func() {}
+	}
+
+	return context.WithDeadline(ctx, deadline)
+}
+
+// cancelBody cancels the context of the request once the body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err
+}
+
+// DefaultTransport returns the transport used when no HTTP client is set with
+// WithHTTPClient, keeping a pool of idle connections to the service.
+func DefaultTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   defaultDialTimeout,
+		KeepAlive: defaultKeepAlive,
+	}
+
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          defaultMaxIdleConns,
+		MaxIdleConnsPerHost:   defaultMaxIdleConns,
+		IdleConnTimeout:       defaultIdleConnTimeout,
+		TLSHandshakeTimeout:   defaultTLSHandshakeTimeout,
+		ExpectContinueTimeout: defaultExpectContinue,
+	}
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ceeideu/sdk/hem"
+	"github.com/ceeideu/sdk/xid"
+)
+
+func TestWithTimeouts(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+
+		if r.URL.Path == KeysRefresh {
+			m, _ := json.Marshal(KeysResp{Encryption: Encryption{ID: 1, Value: testKey}})
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(m)
+
+			return
+		}
+
+		m, _ := json.Marshal(xid.Response{Value: "xid", Status: xid.Okay})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(m)
+	}))
+	defer ts.Close()
+
+	xidClient, _ := NewXID(ts.URL, XApiMockValue, WithHTTPClient(ts.Client()),
+		WithTimeouts(Timeouts{Generate: 10 * time.Millisecond, Keys: time.Second}))
+
+	if _, err := xidClient.Send(context.Background(), hem.FromEmail("test@com")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("XID.Send() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// a deadline set by the caller takes precedence
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if _, err := xidClient.Send(ctx, hem.FromEmail("test@com")); err != nil {
+		t.Errorf("XID.Send() error = %v", err)
+	}
+
+	// the response is read after the call returned, within the timeout
+	if _, err := xidClient.GetKeys(context.Background()); err != nil {
+		t.Errorf("XID.GetKeys() error = %v", err)
+	}
+}
+
+func TestTimeouts_of(t *testing.T) {
+	t.Parallel()
+
+	timeouts := Timeouts{Generate: 1, Refresh: 2, Keys: 3, Default: 4}
+	tests := []struct {
+		op   string
+		want time.Duration
+	}{
+		{op: OpGenerate, want: 1},
+		{op: OpToken, want: 1},
+		{op: OpRefresh, want: 2},
+		{op: OpTokenRefresh, want: 2},
+		{op: OpKeys, want: 3},
+		{op: OpMap, want: 4},
+		{op: OpProbe, want: 4},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.op, func(t *testing.T) {
+			t.Parallel()
+			if got := timeouts.of(test.op); got != test.want {
+				t.Errorf("Timeouts.of() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewXID_DefaultTransport(t *testing.T) {
+	t.Parallel()
+
+	xidClient, _ := NewXID("http://localhost", XApiMockValue)
+
+	c, ok := xidClient.httpClient.(*http.Client)
+	if !ok || c == http.DefaultClient {
+		t.Fatalf("default HTTP client = %v", xidClient.httpClient)
+	}
+
+	tr, ok := c.Transport.(*http.Transport)
+	if !ok || tr.MaxIdleConnsPerHost != defaultMaxIdleConns || tr.TLSHandshakeTimeout != defaultTLSHandshakeTimeout {
+		t.Errorf("default transport = %v", c.Transport)
+	}
+
+	if xidClient.timeouts != DefaultTimeouts() {
+		t.Errorf("default timeouts = %v, want %v", xidClient.timeouts, DefaultTimeouts())
+	}
+}
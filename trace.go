@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ceeideu/sdk/crypto"
+	"github.com/ceeideu/sdk/tracing"
+)
+
+const (
+	SpanGenerate     = "ceeid.xid.generate"
+	SpanRefreshXID   = "ceeid.xid.refresh"
+	SpanKeysRefresh  = "ceeid.keys.refresh"
+	SpanGetKeys      = "ceeid.keys.get"
+	SpanTokenEncrypt = "ceeid.token.encrypt"
+	SpanTokenDecrypt = "ceeid.token.decrypt"
+)
+
+// WithTracer makes the client emit spans for its operations and propagate the
+// trace context on outgoing requests.
+func WithTracer(t tracing.Tracer) func(*XID) {
+	return func(x *XID) {
+		x.tracer = t
+	}
+}
+
+func (x *XID) startSpan(ctx context.Context, name string, attrs ...tracing.Attribute) (context.Context, tracing.Span) {
+	if x.tracer == nil {
+		return tracing.Noop{}.Start(ctx, name, attrs...)
+	}
+
+	return x.tracer.Start(ctx, name, attrs...)
+}
+
+func (x *XID) inject(ctx context.Context, req *http.Request) {
+	if x.tracer != nil {
+		x.tracer.Inject(ctx, req.Header)
+	}
+}
+
+// endSpan records the error class of err, if any, and ends the span.
+func endSpan(span tracing.Span, err error) {
+	if err != nil {
+		span.SetError(errorClass(err))
+	}
+
+	span.End()
+}
+
+// errorClass classifies err for traces, metrics and logs without exposing its message.
+func errorClass(err error) string {
+	var apiErr *APIError
+
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit_open"
+	case errors.As(err, &apiErr):
+		return "http_" + strconv.Itoa(apiErr.StatusCode)
+	case errors.Is(err, ErrUserBlocked):
+		return "user_blocked"
+	case errors.Is(err, ErrConsent):
+		return "consent"
+	case errors.Is(err, ErrCommunication):
+		return "communication"
+	case errors.Is(err, ErrCredentials):
+		return "credentials"
+	case errors.Is(err, ErrInvalidResponse):
+		return "invalid_response"
+	case errors.Is(err, ErrDecode):
+		return "decode"
+	case errors.Is(err, ErrInvalidToken):
+		return "invalid_token"
+	case errors.Is(err, crypto.ErrKeyNotPresent), errors.Is(err, crypto.ErrNilEncKey),
+		errors.Is(err, crypto.ErrOpen), errors.Is(err, crypto.ErrNonceSize):
+		return crypto.Result(err)
+	default:
+		return "other"
+	}
+}
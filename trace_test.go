@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/ceeideu/sdk/crypto"
+	"github.com/ceeideu/sdk/hem"
+	"github.com/ceeideu/sdk/tracing"
+	"github.com/ceeideu/sdk/xid"
+)
+
+type recordedSpan struct {
+	name  string
+	attrs map[string]any
+	class string
+	ended bool
+}
+
+type TracerMock struct {
+	spans []*recordedSpan
+
+	m sync.Mutex
+}
+
+func (tr *TracerMock) Start(ctx context.Context, name string, attrs ...tracing.Attribute) (context.Context, tracing.Span) {
+	tr.m.Lock()
+	defer tr.m.Unlock()
+
+	span := &recordedSpan{name: name, attrs: map[string]any{}}
+	span.SetAttributes(attrs...)
+	tr.spans = append(tr.spans, span)
+
+	return ctx, span
+}
+
+func (tr *TracerMock) Inject(_ context.Context, header http.Header) {
+	header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...tracing.Attribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *recordedSpan) SetError(class string) {
+	s.class = class
+}
+
+func (s *recordedSpan) End() {
+	s.ended = true
+}
+
+func TestWithTracer(t *testing.T) {
+	t.Parallel()
+
+	var traceparents []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents = append(traceparents, r.Header.Get("traceparent"))
+		switch r.URL.Path {
+		case XidGenerate:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"value":"xid","status":"ok"}`))
+		case KeysRefresh:
+			m, _ := json.Marshal(KeysResp{Encryption: Encryption{ID: 7, Value: "foo"}})
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(m)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	tracer := &TracerMock{}
+	xidClient, _ := NewXID(ts.URL, XApiMockValue, WithHTTPClient(ts.Client()), WithTracer(tracer))
+	xidClient.cryptoService = &CryptoMock{
+		encResp: crypto.EncResp{Value: []byte("enc"), EncKeyID: 7},
+		decErr:  crypto.ErrKeyNotPresent,
+	}
+
+	hemReq := hem.FromEmail("test@com")
+	_, _ = xidClient.Send(context.Background(), hemReq)
+	_, _ = xidClient.RefreshXID(context.Background(), xid.RefreshRequest("xid"))
+	_ = xidClient.Refresh(context.Background())
+	_, _ = xidClient.TokenFromXID("xid")
+	_, _ = xidClient.DecryptToken(xid.NewToken(3, xid.Value("foo")))
+
+	want := []recordedSpan{
+		{
+			name:  SpanGenerate,
+			attrs: map[string]any{tracing.AttrOperation: OpGenerate, tracing.AttrStatus: xid.Okay},
+			ended: true,
+		},
+		{
+			name:  SpanRefreshXID,
+			attrs: map[string]any{tracing.AttrOperation: OpRefresh, tracing.AttrStatus: xid.Unknown},
+			class: "http_400",
+			ended: true,
+		},
+		{
+			name: SpanKeysRefresh,
+			attrs: map[string]any{
+				tracing.AttrOperation: OpKeys, tracing.AttrFresh: false, tracing.AttrNotMod: false, tracing.AttrKeyID: 7,
+			},
+			ended: true,
+		},
+		{
+			name:  SpanTokenEncrypt,
+			attrs: map[string]any{tracing.AttrKeyID: 7},
+			ended: true,
+		},
+		{
+			name:  SpanTokenDecrypt,
+			attrs: map[string]any{tracing.AttrKeyID: 3},
+			class: "key_not_present",
+			ended: true,
+		},
+	}
+	if len(tracer.spans) != len(want) {
+		t.Fatalf("got %d spans, want %d", len(tracer.spans), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(*tracer.spans[i], want[i]) {
+			t.Errorf("span %d = %+v, want %+v", i, *tracer.spans[i], want[i])
+		}
+		for _, v := range tracer.spans[i].attrs {
+			if fmt.Sprint(v) == hemReq.Value {
+				t.Errorf("span %d carries the HEM", i)
+			}
+		}
+	}
+
+	if len(traceparents) != 3 || traceparents[0] == "" {
+		t.Errorf("traceparent headers = %v, want 3 propagated", traceparents)
+	}
+}
+
+func Test_errorClass(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "nil", err: nil, want: ""},
+		{name: "timeout", err: fmt.Errorf("%w: %w", ErrCommunication, context.DeadlineExceeded), want: "timeout"},
+		{name: "api", err: fmt.Errorf("%w: %w", ErrDoHTTPReq, &APIError{StatusCode: http.StatusUnauthorized}), want: "http_401"},
+		{name: "circuit", err: ErrCircuitOpen, want: "circuit_open"},
+		{name: "consent", err: ErrConsent, want: "consent"},
+		{name: "invalid response", err: fmt.Errorf("%w: missing xid value", ErrInvalidResponse), want: "invalid_response"},
+		{name: "credentials", err: fmt.Errorf("%w: %w", ErrCredentials, ErrNoAPIKey), want: "credentials"},
+		{name: "key", err: fmt.Errorf("%s: %w", "decrypt error", crypto.ErrKeyNotPresent), want: "key_not_present"},
+		{name: "other", err: Err, want: "other"},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if got := errorClass(test.err); got != test.want {
+				t.Errorf("errorClass() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
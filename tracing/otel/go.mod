@@ -0,0 +1,22 @@
+module github.com/ceeideu/sdk/tracing/otel
+
+go 1.21.0
+
+require (
+	github.com/ceeideu/sdk v0.1.0
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/sdk v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	go.opentelemetry.io/otel/metric v1.24.0 // indirect
+	golang.org/x/sys v0.17.0 // indirect
+)
+
+// The SDK in this repository is used for development, modules depending on
+// this one resolve the required release, the first one with the tracing
+// package.
+replace github.com/ceeideu/sdk => ../..
@@ -0,0 +1,86 @@
+// Package otel adapts OpenTelemetry to the tracing.Tracer interface of the SDK.
+// It is a separate module, so the SDK itself stays dependency-free.
+package otel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/ceeideu/sdk/tracing"
+	otelapi "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const InstrumentationName = "github.com/ceeideu/sdk"
+
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// NewTracer returns a tracing.Tracer creating spans with tp. A nil tp means
+// the global TracerProvider; a nil propagator the global TextMapPropagator.
+func NewTracer(tp trace.TracerProvider, propagator propagation.TextMapPropagator) *Tracer {
+	if tp == nil {
+		tp = otelapi.GetTracerProvider()
+	}
+
+	if propagator == nil {
+		propagator = otelapi.GetTextMapPropagator()
+	}
+
+	return &Tracer{
+		tracer:     tp.Tracer(InstrumentationName),
+		propagator: propagator,
+	}
+}
+
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...tracing.Attribute) (context.Context, tracing.Span) {
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(convert(attrs)...))
+
+	return ctx, &Span{span: span}
+}
+
+func (t *Tracer) Inject(ctx context.Context, header http.Header) {
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+type Span struct {
+	span trace.Span
+}
+
+func (s *Span) SetAttributes(attrs ...tracing.Attribute) {
+	s.span.SetAttributes(convert(attrs)...)
+}
+
+func (s *Span) SetError(class string) {
+	s.span.SetAttributes(attribute.String(tracing.AttrErrorClass, class))
+	s.span.SetStatus(codes.Error, class)
+}
+
+func (s *Span) End() {
+	s.span.End()
+}
+
+func convert(attrs []tracing.Attribute) []attribute.KeyValue {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+
+	for _, a := range attrs {
+		switch v := a.Value.(type) {
+		case string:
+			kvs = append(kvs, attribute.String(a.Key, v))
+		case int:
+			kvs = append(kvs, attribute.Int(a.Key, v))
+		case bool:
+			kvs = append(kvs, attribute.Bool(a.Key, v))
+		default:
+			kvs = append(kvs, attribute.String(a.Key, fmt.Sprint(v)))
+		}
+	}
+
+	return kvs
+}
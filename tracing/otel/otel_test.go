@@ -0,0 +1,79 @@
+package otel
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ceeideu/sdk/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		attrs      []tracing.Attribute
+		errClass   string
+		wantAttrs  []attribute.KeyValue
+		wantStatus codes.Code
+	}{
+		{
+			name:       "ok",
+			attrs:      []tracing.Attribute{tracing.String(tracing.AttrOperation, "generate"), tracing.Int(tracing.AttrKeyID, 7)},
+			wantAttrs:  []attribute.KeyValue{attribute.String(tracing.AttrOperation, "generate"), attribute.Int(tracing.AttrKeyID, 7)},
+			wantStatus: codes.Unset,
+		},
+		{
+			name:       "error",
+			attrs:      []tracing.Attribute{tracing.Bool(tracing.AttrFresh, false)},
+			errClass:   "timeout",
+			wantAttrs:  []attribute.KeyValue{attribute.Bool(tracing.AttrFresh, false), attribute.String(tracing.AttrErrorClass, "timeout")},
+			wantStatus: codes.Error,
+		},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			recorder := tracetest.NewSpanRecorder()
+			tracer := NewTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)), propagation.TraceContext{})
+
+			ctx, span := tracer.Start(context.Background(), "span", test.attrs...)
+			if test.errClass != "" {
+				span.SetError(test.errClass)
+			}
+
+			header := http.Header{}
+			tracer.Inject(ctx, header)
+			span.End()
+
+			if header.Get("traceparent") == "" {
+				t.Errorf("Inject() did not set traceparent")
+			}
+
+			ended := recorder.Ended()
+			if len(ended) != 1 {
+				t.Fatalf("got %d spans, want 1", len(ended))
+			}
+
+			got := ended[0].Attributes()
+			if len(got) != len(test.wantAttrs) {
+				t.Fatalf("attributes = %v, want %v", got, test.wantAttrs)
+			}
+			for i := range got {
+				if got[i] != test.wantAttrs[i] {
+					t.Errorf("attribute %d = %v, want %v", i, got[i], test.wantAttrs[i])
+				}
+			}
+
+			if ended[0].Status().Code != test.wantStatus {
+				t.Errorf("status = %v, want %v", ended[0].Status().Code, test.wantStatus)
+			}
+		})
+	}
+}
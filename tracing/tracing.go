@@ -0,0 +1,67 @@
+// Package tracing defines the dependency-free tracer interface used by the
+// SDK. An OpenTelemetry adapter is provided by the tracing/otel module.
+package tracing
+
+import (
+	"context"
+	"net/http"
+)
+
+const (
+	AttrOperation  = "ceeid.operation"
+	AttrStatus     = "ceeid.status"
+	AttrKeyID      = "ceeid.key_id"
+	AttrNotMod     = "ceeid.keys.not_modified"
+	AttrFresh      = "ceeid.keys.fresh"
+	AttrErrorClass = "error.type"
+)
+
+// Attribute is a span attribute. Value is a string, int or bool.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+type Tracer interface {
+	// Start starts a span as a child of the span in ctx, if any.
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+	// Inject writes the trace context of ctx into the headers of an outgoing request.
+	Inject(ctx context.Context, header http.Header)
+}
+
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	// SetError marks the span as failed. Only the error class is passed, so
+	// no personal data carried by error messages ends up in traces.
+	SetError(class string)
+	End()
+}
+
+// Noop is a Tracer doing nothing.
+type Noop struct{}
+
+func (Noop) Start(ctx context.Context, _ string, _ ...Attribute) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+func (Noop) Inject(context.Context, http.Header) {}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute) {}
+
+func (noopSpan) SetError(string) {}
+
+func (noopSpan) End() {}
@@ -4,7 +4,10 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"log/slog"
 	"strconv"
+
+	"github.com/ceeideu/sdk/properties"
 )
 
 var ErrTokenLen = errors.New("token len err")
@@ -17,10 +20,22 @@ type TokenRefreshReq struct {
 	XID string `json:"xid"`
 }
 
+func TokenRefreshRequest(xid string) TokenRefreshReq {
+	return TokenRefreshReq{XID: xid}
+}
+
 type TokenRefreshResp struct {
 	Token string `json:"token"`
 }
 
+type DecodeReq struct {
+	Token string `json:"token"`
+}
+
+type DecodeResp struct {
+	Value string `json:"value"`
+}
+
 type Token string
 
 func NewToken(keyID uint8, xid Value) Token {
@@ -55,3 +70,8 @@ func (t Token) Key() (uint8, error) {
 func (t Token) String() string {
 	return string(t)
 }
+
+// LogValue implements slog.LogValuer, redacting the token.
+func (t Token) LogValue() slog.Value {
+	return slog.StringValue(properties.Redacted)
+}
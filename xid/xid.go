@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/base32"
 	"fmt"
+	"log/slog"
 
 	"github.com/ceeideu/sdk/properties"
 )
@@ -83,6 +84,19 @@ func (t TypeOf) String() string {
 
 type StatusOf byte
 
+func StatusFromString(s string) StatusOf {
+	switch s {
+	case Okay:
+		return StatusOfOK
+	case UserBlocked:
+		return StatusOfUserBlocked
+	case InvalidConsent:
+		return StatusOfInvalidConsent
+	default:
+		return StatusOfUnknown
+	}
+}
+
 func (t StatusOf) String() string {
 	switch t {
 	case StatusOfOK:
@@ -169,11 +183,94 @@ func (r RefreshReq) WithProperties(_properties properties.Value) RefreshReq {
 	return r
 }
 
+// LogValue implements slog.LogValuer, redacting the xID.
+func (r RefreshReq) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("xid", properties.Redacted),
+		slog.Any("properties", properties.Value(r.Properties)),
+	)
+}
+
 type RefreshResp struct {
-	Value string `json:"value"`
+	Value  string `json:"value"`
+	Status string `json:"status"`
+}
+
+func (r RefreshResp) StatusOf() StatusOf {
+	return StatusFromString(r.Status)
+}
+
+func (r RefreshResp) IsOK() bool {
+	return r.StatusOf() == StatusOfOK
 }
 
 type Response struct {
 	Value  string `json:"value"`
 	Status string `json:"status"`
 }
+
+func (r Response) StatusOf() StatusOf {
+	return StatusFromString(r.Status)
+}
+
+func (r Response) IsOK() bool {
+	return r.StatusOf() == StatusOfOK
+}
+
+type Identifier struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type MapReq struct {
+	Identifiers []Identifier      `json:"identifiers"`
+	Properties  map[string]string `json:"properties"`
+}
+
+func MapRequest(identifiers ...Identifier) MapReq {
+	return MapReq{Identifiers: identifiers}
+}
+
+func (r MapReq) WithProperties(_properties properties.Value) MapReq {
+	r.Properties = _properties
+
+	return r
+}
+
+type Mapping struct {
+	Type   string `json:"type"`
+	Value  string `json:"value"`
+	XID    string `json:"xid"`
+	Status string `json:"status"`
+}
+
+func (m Mapping) StatusOf() StatusOf {
+	return StatusFromString(m.Status)
+}
+
+type MapResp struct {
+	Mappings []Mapping `json:"mappings"`
+}
+
+type LookupReq struct {
+	XID string `json:"xid"`
+}
+
+func LookupRequest(xid string) LookupReq {
+	return LookupReq{XID: xid}
+}
+
+type LookupResp struct {
+	XID    string `json:"xid"`
+	Known  bool   `json:"known"`
+	Status string `json:"status"`
+}
+
+func (r LookupResp) StatusOf() StatusOf {
+	return StatusFromString(r.Status)
+}
+
+// Active reports whether the xID is known and its status is ok.
+func (r LookupResp) Active() bool {
+	return r.Known && r.StatusOf() == StatusOfOK
+}
@@ -159,3 +159,81 @@ func TestRefreshReq_WithProperties(t *testing.T) {
 		})
 	}
 }
+
+func TestMapReq_WithProperties(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		identifiers []Identifier
+		_properties properties.Value
+		want        MapReq
+	}{
+		{
+			name:        "1",
+			identifiers: []Identifier{{Type: Hex, Value: "foo"}},
+			_properties: properties.WithConsent("foo"),
+			want: MapReq{
+				Identifiers: []Identifier{{Type: Hex, Value: "foo"}},
+				Properties:  map[string]string{"consent": "foo"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if got := MapRequest(test.identifiers...).WithProperties(test._properties); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("MapReq.WithProperties() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestStatusFromString(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		s    string
+		want StatusOf
+	}{
+		{name: "ok", s: Okay, want: StatusOfOK},
+		{name: "blocked", s: UserBlocked, want: StatusOfUserBlocked},
+		{name: "consent", s: InvalidConsent, want: StatusOfInvalidConsent},
+		{name: "empty", s: "", want: StatusOfUnknown},
+		{name: "foo", s: "foo", want: StatusOfUnknown},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if got := StatusFromString(test.s); got != test.want {
+				t.Errorf("StatusFromString() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestResponse_IsOK(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		resp Response
+		want bool
+	}{
+		{name: "ok", resp: Response{Status: Okay}, want: true},
+		{name: "blocked", resp: Response{Status: UserBlocked}, want: false},
+		{name: "empty", resp: Response{}, want: false},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if got := test.resp.IsOK(); got != test.want {
+				t.Errorf("Response.IsOK() = %v, want %v", got, test.want)
+			}
+			if got := (RefreshResp{Status: test.resp.Status}).IsOK(); got != test.want {
+				t.Errorf("RefreshResp.IsOK() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
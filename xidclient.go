@@ -7,12 +7,16 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"runtime/debug"
+	"time"
 
 	"github.com/ceeideu/sdk/crypto"
 	"github.com/ceeideu/sdk/hem"
+	"github.com/ceeideu/sdk/metrics"
+	"github.com/ceeideu/sdk/tracing"
 	"github.com/ceeideu/sdk/xid"
 )
 
@@ -23,6 +27,9 @@ const (
 	Lookup      = "/lookup"
 	Decode      = "/decode"
 	XidGenerate = Xid + Generate
+	XidMap      = Xid + Map
+	XidLookup   = Xid + Lookup
+	XidDecode   = Xid + Decode
 
 	Keys        = "/keys"
 	Token       = "/token"
@@ -30,6 +37,8 @@ const (
 	KeysRefresh = Keys + Refresh
 	XidRefresh  = Xid + Refresh
 
+	TokenRefresh = Token + Refresh
+
 	XApiKeyHeader = "x-api-key"
 	XApiMockValue = "[X-API-VALUE]"
 
@@ -39,10 +48,43 @@ const (
 
 type XID struct {
 	baseURL       *url.URL
-	authToken     string
+	credentials   CredentialProvider
 	httpClient    HTTPDoer
 	cryptoService Crypto
 	SDKVersion    string
+
+	keys      keysState
+	now       func() time.Time
+	refresher refresher
+	lifecycle lifecycle
+
+	retry       RetryPolicy
+	retryBudget *retryBudget
+
+	batch   batchConfig
+	cache   responseCache
+	flights flights
+	outbox  outboxConfig
+
+	snapshots crypto.SnapshotStore
+
+	maxResponseSize int64
+	timeouts        Timeouts
+
+	endpoints    []*endpoint
+	fallbackURLs []string
+	failover     FailoverConfig
+
+	decodeMode DecodeMode
+
+	breakers *breakers
+
+	interceptors []Interceptor
+	doer         HTTPDoer
+
+	tracer  tracing.Tracer
+	metrics metrics.Metrics
+	logger  *slog.Logger
 }
 
 type Crypto interface {
@@ -62,12 +104,31 @@ var (
 	ErrDecrypt       = errors.New("decrypt error")
 	ErrOpen          = errors.New("open error")
 	ErrConsent       = errors.New("no consent")
+	ErrUserBlocked   = errors.New("user blocked")
+	ErrNotModified   = errors.New("not modified")
+	ErrInvalidToken  = errors.New("invalid token")
 )
 
 type HTTPDoer interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
+// DecodeMode selects how DecodeToken decodes tokens.
+type DecodeMode byte
+
+const (
+	// DecodeModeLocal decrypts tokens with the locally held keys.
+	DecodeModeLocal = DecodeMode(0)
+	// DecodeModeRemote sends tokens to the service for decoding.
+	DecodeModeRemote = DecodeMode(1)
+)
+
+func WithDecodeMode(m DecodeMode) func(*XID) {
+	return func(x *XID) {
+		x.decodeMode = m
+	}
+}
+
 func WithHTTPClient(c HTTPDoer) func(*XID) {
 	return func(x *XID) {
 		x.httpClient = c
@@ -86,39 +147,79 @@ func NewXID(baseURL, authToken string, opts ...func(*XID)) (*XID, error) {
 	}
 
 	_xid := &XID{
-		cryptoService: crypto.NewService(),
-		SDKVersion:    SDKPrefix + sdkVer,
+		SDKVersion: SDKPrefix + sdkVer,
+		now:        time.Now,
+		timeouts:   DefaultTimeouts(),
 	}
 
 	for _, o := range opts {
 		o(_xid)
 	}
+
+	_xid.cryptoService = crypto.NewService(crypto.WithMetrics(_xid.metrics))
 	u, err := url.Parse(baseURL)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrParse, err)
 	}
 
 	_xid.baseURL = u
-	_xid.authToken = authToken
+
+	if err := _xid.newEndpoints(u); err != nil {
+		return nil, err
+	}
+
+	if _xid.credentials == nil {
+		_xid.credentials = StaticCredentials(authToken)
+	}
 
 	if _xid.httpClient == nil {
-		_xid.httpClient = http.DefaultClient
+		_xid.httpClient = &http.Client{Transport: DefaultTransport()}
+	}
+
+	_xid.doer = chain(_xid.httpClient, _xid.interceptors)
+	_xid.loadSnapshot()
+
+	if _xid.refresher.auto {
+		if err := _xid.Start(context.Background()); err != nil {
+			return nil, err
+		}
 	}
 
 	return _xid, nil
 }
 
 func (x *XID) DecryptToken(token xid.Token) (string, error) {
+	return x.DecryptTokenContext(context.Background(), token)
+}
+
+// DecryptTokenContext is DecryptToken with a context carrying the parent span.
+func (x *XID) DecryptTokenContext(ctx context.Context, token xid.Token) (string, error) {
+	_, span := x.startSpan(ctx, SpanTokenDecrypt)
+
+	decrypted, err := x.decrypt(token, span)
+	endSpan(span, err)
+
+	if err != nil {
+		x.meter().IncCounter(metrics.DecryptFailuresTotal, metrics.Label{Name: metrics.LabelReason, Value: errorClass(err)})
+		x.log().LogAttrs(ctx, slog.LevelDebug, "ceeid token decrypt failed", slog.String(LogErrorClass, errorClass(err)))
+	}
+
+	return decrypted, err
+}
+
+func (x *XID) decrypt(token xid.Token, span tracing.Span) (string, error) {
 	keyID, err := token.Key()
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", "key error", err)
+		return "", fmt.Errorf("%w: %s: %w", ErrInvalidToken, "key error", err)
 	}
 
 	_xid, err := token.XID()
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", "xid error", err)
+		return "", fmt.Errorf("%w: %s: %w", ErrInvalidToken, "xid error", err)
 	}
 
+	span.SetAttributes(tracing.Int(tracing.AttrKeyID, int(keyID)))
+
 	decrypted, err := x.cryptoService.Decrypt(keyID, _xid)
 	if err != nil {
 		return "", fmt.Errorf("%s: %w", "decrypt error", err)
@@ -127,29 +228,141 @@ func (x *XID) DecryptToken(token xid.Token) (string, error) {
 	return string(decrypted), nil
 }
 
+// DecodeRemote sends the token to the service for authoritative decoding. It
+// returns the same value as DecryptToken, without holding decryption keys.
+func (x *XID) DecodeRemote(ctx context.Context, token xid.Token) (string, error) {
+	_bytes, err := json.Marshal(xid.DecodeReq{Token: token.String()})
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrMarshal, err)
+	}
+
+	resp, err := x.DoHTTPReq(ctx, http.MethodPost, x.baseURL.String()+XidDecode, _bytes)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrDoHTTPReq, err)
+	}
+	defer drain(resp)
+
+	var decodeResp xid.DecodeResp
+
+	err = x.decodeResponse(resp, &decodeResp)
+	if err != nil {
+		return "", err
+	}
+
+	return decodeResp.Value, nil
+}
+
+// DecodeToken decodes the token locally or remotely, depending on the decode mode.
+func (x *XID) DecodeToken(ctx context.Context, token xid.Token) (string, error) {
+	if x.decodeMode == DecodeModeRemote {
+		return x.DecodeRemote(ctx, token)
+	}
+
+	return x.DecryptTokenContext(ctx, token)
+}
+
+// RefreshXID refreshes the xID. When the service reports the user as blocked
+// or the consent as invalid, the response is returned together with an error
+// wrapping ErrUserBlocked or ErrConsent.
 func (x *XID) RefreshXID(ctx context.Context, refreshReq xid.RefreshReq) (xid.RefreshResp, error) {
+	ctx, span := x.startSpan(ctx, SpanRefreshXID, tracing.String(tracing.AttrOperation, OpRefresh))
+
+	refreshResp, err := x.refreshXID(ctx, refreshReq)
+	span.SetAttributes(tracing.String(tracing.AttrStatus, refreshResp.StatusOf().String()))
+	endSpan(span, err)
+	x.logResult(ctx, OpRefresh, slog.Any("request", refreshReq), refreshResp.StatusOf(), err)
+
+	return refreshResp, err
+}
+
+func (x *XID) refreshXID(ctx context.Context, refreshReq xid.RefreshReq) (xid.RefreshResp, error) {
 	_bytes, err := json.Marshal(refreshReq)
 	if err != nil {
 		return xid.RefreshResp{}, fmt.Errorf("%w: %w", ErrMarshal, err)
 	}
 
-	resp, err := x.DoHTTPReq(ctx, http.MethodPost, x.baseURL.String()+XidRefresh, _bytes)
+	return x.flights.refresh.do(ctx, string(_bytes), func(ctx context.Context) (xid.RefreshResp, error) {
+		return x.postRefreshXID(ctx, _bytes)
+	})
+}
+
+func (x *XID) postRefreshXID(ctx context.Context, body []byte) (xid.RefreshResp, error) {
+	resp, err := x.DoHTTPReq(ctx, http.MethodPost, x.baseURL.String()+XidRefresh, body)
 	if err != nil {
 		return xid.RefreshResp{}, fmt.Errorf("%w:%w", ErrDoHTTPReq, err)
 	}
 
-	defer resp.Body.Close()
+	defer drain(resp)
 
 	var refreshResp xid.RefreshResp
-	err = json.NewDecoder(resp.Body).Decode(&refreshResp)
+	err = x.decodeResponse(resp, &refreshResp)
 	if err != nil {
-		return xid.RefreshResp{}, fmt.Errorf("%w: %w", ErrDecode, err)
+		return xid.RefreshResp{}, err
 	}
 
-	return refreshResp, nil
+	return refreshResp, statusError(refreshResp.StatusOf())
+}
+
+// Map maps identifiers held by the partner to xIDs.
+func (x *XID) Map(ctx context.Context, mapReq xid.MapReq) (xid.MapResp, error) {
+	_bytes, err := json.Marshal(mapReq)
+	if err != nil {
+		return xid.MapResp{}, fmt.Errorf("%w: %w", ErrMarshal, err)
+	}
+
+	resp, err := x.DoHTTPReq(ctx, http.MethodPost, x.baseURL.String()+XidMap, _bytes)
+	if err != nil {
+		return xid.MapResp{}, fmt.Errorf("%w: %w", ErrDoHTTPReq, err)
+	}
+	defer drain(resp)
+
+	var mapResp xid.MapResp
+
+	err = x.decodeResponse(resp, &mapResp)
+	if err != nil {
+		return xid.MapResp{}, err
+	}
+
+	return mapResp, nil
+}
+
+// Lookup checks whether an xID is known to the service and what its status is.
+func (x *XID) Lookup(ctx context.Context, lookupReq xid.LookupReq) (xid.LookupResp, error) {
+	_bytes, err := json.Marshal(lookupReq)
+	if err != nil {
+		return xid.LookupResp{}, fmt.Errorf("%w: %w", ErrMarshal, err)
+	}
+
+	resp, err := x.DoHTTPReq(ctx, http.MethodPost, x.baseURL.String()+XidLookup, _bytes)
+	if err != nil {
+		return xid.LookupResp{}, fmt.Errorf("%w: %w", ErrDoHTTPReq, err)
+	}
+	defer drain(resp)
+
+	var lookupResp xid.LookupResp
+
+	err = x.decodeResponse(resp, &lookupResp)
+	if err != nil {
+		return xid.LookupResp{}, err
+	}
+
+	return lookupResp, nil
 }
 
 func (x *XID) DoHTTPReq(ctx context.Context, method, _url string, body []byte) (*http.Response, error) {
+	resp, err := x.send(ctx, method, _url, body, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+
+	return resp, nil
+}
+
+func (x *XID) newRequest(ctx context.Context, method, _url string, body []byte) (*http.Request, error) {
 	var bReader io.Reader
 	if body != nil {
 		bReader = bytes.NewBuffer(body)
@@ -167,56 +380,297 @@ func (x *XID) DoHTTPReq(ctx context.Context, method, _url string, body []byte) (
 		return nil, fmt.Errorf("%w: %w", ErrRequest, err)
 	}
 
-	req.Header.Set(XApiKeyHeader, x.authToken)
+	key, err := x.apiKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set(XApiKeyHeader, key)
 	req.Header.Set(SDKVersion, x.SDKVersion)
 
-	resp, err := x.httpClient.Do(req)
+	return req, nil
+}
+
+// send performs the request within the timeout of the operation, retrying it
+// according to the retry policy. prepare, when not nil, is applied to the
+// request of every attempt.
+func (x *XID) send(ctx context.Context, method, _url string, body []byte, prepare func(*http.Request)) (*http.Response, error) {
+	u, err := url.Parse(_url)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %w", ErrCommunication, err)
+		return nil, fmt.Errorf("%w: %w", ErrRequest, err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("%w, got: %v", ErrStatusNotOK, resp.StatusCode)
+	origin, path := x.resolve(u)
+
+	if OperationFromContext(ctx) == "" {
+		ctx = WithOperation(ctx, operationOf(path))
 	}
 
+	ctx, cancel := x.withTimeout(ctx)
+
+	resp, err := x.sendAttempts(ctx, method, target{raw: _url, u: u, origin: origin, path: path}, body, prepare)
+	if err != nil {
+		cancel()
+
+		return nil, err
+	}
+
+	resp.Body = cancelBody{ReadCloser: resp.Body, cancel: cancel}
+
 	return resp, nil
 }
 
+// target is the URL of a call and the endpoint it belongs to, if any.
+type target struct {
+	raw    string
+	u      *url.URL
+	origin *endpoint
+	path   string
+}
+
+// sendAttempts sends the call to t, retrying and failing over as allowed.
+func (x *XID) sendAttempts(ctx context.Context, method string, t target, body []byte, prepare func(*http.Request)) (*http.Response, error) {
+	key := idempotencyKeyFrom(ctx)
+	retryable := key != "" || idempotent(method)
+	attempts := max(x.retry.MaxAttempts, 1)
+
+	x.retryBudget.deposit()
+
+	tried := map[*endpoint]bool{}
+	reauthenticated := false
+
+	for attempt := 0; ; attempt++ {
+		ep, br, err := x.route(t.origin != nil, t.path, tried)
+		if err != nil {
+			x.log().LogAttrs(ctx, slog.LevelWarn, "ceeid circuit open",
+				slog.String(LogOperation, OperationFromContext(ctx)), slog.String(LogEndpoint, t.path))
+
+			return nil, err
+		}
+
+		attemptURL := t.raw
+		if ep != nil && ep != t.origin {
+			attemptURL = ep.rewrite(t.u, t.path)
+		}
+
+		req, err := x.newRequest(ctx, method, attemptURL, body)
+		if err != nil {
+			br.release()
+
+			return nil, err
+		}
+
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+
+		x.inject(ctx, req)
+
+		if prepare != nil {
+			prepare(req)
+		}
+
+		start := x.clock()
+		resp, err := x.do(req)
+		br.observe(ctx, resp, err, x.clock())
+
+		if ep != nil {
+			tried[ep] = tried[ep] || retryableResponse(resp, err)
+			x.observeEndpoint(ctx, ep, resp, err)
+			recordCall(ctx, ep, attempt)
+		}
+
+		x.observeRequest(ctx, resp, err, x.clock().Sub(start))
+		x.logAttempt(ctx, req, attempt, resp, err, x.clock().Sub(start))
+
+		if !reauthenticated && x.reauthenticate(ctx, req, resp) {
+			reauthenticated = true
+
+			drain(resp)
+
+			continue
+		}
+
+		if !retryable || attempt+1 >= attempts || ctx.Err() != nil || !retryableResponse(resp, err) {
+			return resp, err
+		}
+
+		delay, ok := x.retry.delay(resp, attempt, x.clock())
+		if !ok || !x.retryBudget.withdraw() {
+			return resp, err
+		}
+
+		x.logRetry(ctx, req, attempt, resp, err, delay)
+		drain(resp)
+
+		if err := sleep(ctx, delay); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrCommunication, err)
+		}
+	}
+}
+
+// endpointPath returns the path of u relative to its endpoint, e.g. XidGenerate.
+func (x *XID) endpointPath(u *url.URL) string {
+	_, path := x.resolve(u)
+
+	return path
+}
+
+func (x *XID) do(req *http.Request) (*http.Response, error) {
+	doer := x.doer
+	if doer == nil {
+		doer = x.httpClient
+	}
+
+	resp, err := doer.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCommunication, err)
+	}
+
+	return resp, nil
+}
+
+// Send generates the xID for the HEM. When the service reports the user as
+// blocked or the consent as invalid, the response is returned together with an
+// error wrapping ErrUserBlocked or ErrConsent.
 func (x *XID) Send(ctx context.Context, hemReq hem.Request) (xid.Response, error) {
+	ctx, span := x.startSpan(ctx, SpanGenerate, tracing.String(tracing.AttrOperation, OpGenerate))
+
+	xidResp, err := x.generate(ctx, hemReq)
+	span.SetAttributes(tracing.String(tracing.AttrStatus, xidResp.StatusOf().String()))
+	endSpan(span, err)
+	x.logResult(ctx, OpGenerate, slog.Any("request", hemReq), xidResp.StatusOf(), err)
+
+	return xidResp, err
+}
+
+func (x *XID) generate(ctx context.Context, hemReq hem.Request) (xid.Response, error) {
 	if hemReq.Err != nil {
 		return xid.Response{}, fmt.Errorf("%s: %w", "hem request error", hemReq.Err)
 	}
 
+	if xidResp, ok := x.cachedResponse(hemReq); ok {
+		return xidResp, nil
+	}
+
 	_bytes, err := json.Marshal(hemReq)
 	if err != nil {
 		return xid.Response{}, fmt.Errorf("%w: %w", ErrMarshal, err)
 	}
 
-	resp, err := x.DoHTTPReq(ctx, http.MethodPost, x.baseURL.String()+XidGenerate, _bytes)
+	return x.flights.generate.do(ctx, string(_bytes), func(ctx context.Context) (xid.Response, error) {
+		xidResp, err := x.postGenerate(ctx, hemReq, _bytes)
+
+		return xidResp, x.enqueue(ctx, hemReq, err)
+	})
+}
+
+func (x *XID) postGenerate(ctx context.Context, hemReq hem.Request, body []byte) (xid.Response, error) {
+	resp, err := x.DoHTTPReq(ctx, http.MethodPost, x.baseURL.String()+XidGenerate, body)
 	if err != nil {
 		return xid.Response{}, fmt.Errorf("%w: %w", ErrDoHTTPReq, err)
 	}
-	defer resp.Body.Close()
+	defer drain(resp)
 
 	xidResp := xid.Response{}
 
-	err = json.NewDecoder(resp.Body).Decode(&xidResp)
+	err = x.decodeResponse(resp, &xidResp)
 	if err != nil {
-		return xid.Response{}, fmt.Errorf("%w: %w", ErrDecode, err)
+		return xid.Response{}, err
 	}
 
-	return xidResp, nil
+	x.cacheResponse(hemReq, xidResp)
+
+	return xidResp, statusError(xidResp.StatusOf())
+}
+
+// statusError maps statuses the caller has to react to to ErrUserBlocked and ErrConsent.
+func statusError(status xid.StatusOf) error {
+	switch status {
+	case xid.StatusOfUserBlocked:
+		return fmt.Errorf("%w, status: %s", ErrUserBlocked, status)
+	case xid.StatusOfInvalidConsent:
+		return fmt.Errorf("%w, status: %s", ErrConsent, status)
+	case xid.StatusOfOK, xid.StatusOfUnknown:
+		return nil
+	default:
+		return nil
+	}
 }
 
 func (x *XID) TokenFromXID(_xid string) (xid.Token, error) {
+	return x.TokenFromXIDContext(context.Background(), _xid)
+}
+
+// TokenFromXIDContext is TokenFromXID with a context carrying the parent span.
+func (x *XID) TokenFromXIDContext(ctx context.Context, _xid string) (xid.Token, error) {
+	_, span := x.startSpan(ctx, SpanTokenEncrypt)
+
 	enc, err := x.cryptoService.Encrypt([]byte(_xid))
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", "encrypt error", err)
+		err = fmt.Errorf("%s: %w", "encrypt error", err)
+		endSpan(span, err)
+
+		return "", err
 	}
 
+	span.SetAttributes(tracing.Int(tracing.AttrKeyID, int(enc.EncKeyID)))
+	endSpan(span, nil)
+
 	return xid.NewToken(enc.EncKeyID, xid.Value(enc.Value)), nil
 }
 
+// IssueToken lets the service generate a bid-stream token for the HEM, so no
+// encryption keys have to be managed locally.
+func (x *XID) IssueToken(ctx context.Context, hemReq hem.Request) (xid.Token, error) {
+	if hemReq.Err != nil {
+		return "", fmt.Errorf("%s: %w", "hem request error", hemReq.Err)
+	}
+
+	_bytes, err := json.Marshal(hemReq)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrMarshal, err)
+	}
+
+	resp, err := x.DoHTTPReq(ctx, http.MethodPost, x.baseURL.String()+Token, _bytes)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrDoHTTPReq, err)
+	}
+	defer drain(resp)
+
+	var tokenResp xid.TokenResponse
+
+	err = x.decodeResponse(resp, &tokenResp)
+	if err != nil {
+		return "", err
+	}
+
+	return xid.Token(tokenResp.Value), nil
+}
+
+// RefreshToken lets the service refresh the xID and return it as a bid-stream token.
+func (x *XID) RefreshToken(ctx context.Context, refreshReq xid.TokenRefreshReq) (xid.Token, error) {
+	_bytes, err := json.Marshal(refreshReq)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrMarshal, err)
+	}
+
+	resp, err := x.DoHTTPReq(ctx, http.MethodPost, x.baseURL.String()+TokenRefresh, _bytes)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrDoHTTPReq, err)
+	}
+	defer drain(resp)
+
+	var refreshResp xid.TokenRefreshResp
+
+	err = x.decodeResponse(resp, &refreshResp)
+	if err != nil {
+		return "", err
+	}
+
+	return xid.Token(refreshResp.Token), nil
+}
+
 type KeysResp struct {
 	Decryption map[uint8]string `json:"decryption"`
 	Encryption Encryption       `json:"encryption"`
@@ -227,39 +681,130 @@ type Encryption struct {
 	Value string `json:"value"`
 }
 
+// Refresh fetches encryption keys and applies them to the crypto service.
+// No request is made while previously fetched keys are still fresh according
+// to the Cache-Control/Expires headers of the last keys response; once stale,
+// the request is conditional and a 304 answer keeps the current keys.
 func (x *XID) Refresh(ctx context.Context) error {
-	resp, err := x.GetKeys(ctx)
+	ctx, span := x.startSpan(ctx, SpanKeysRefresh, tracing.String(tracing.AttrOperation, OpKeys))
+
+	outcome, err := x.refresh(ctx)
+	x.observeRefresh(outcome, err)
+	x.logRefresh(ctx, outcome, err)
+	span.SetAttributes(
+		tracing.Bool(tracing.AttrFresh, outcome.fresh),
+		tracing.Bool(tracing.AttrNotMod, outcome.notModified),
+	)
+
+	if outcome.applied {
+		span.SetAttributes(tracing.Int(tracing.AttrKeyID, int(outcome.keyID)))
+	}
+
+	endSpan(span, err)
+
+	return err
+}
+
+// refreshOutcome tells how a refresh ended, for instrumentation.
+type refreshOutcome struct {
+	// cached keys were still fresh, no request made.
+	fresh bool
+	// service answered the cached keys are current.
+	notModified bool
+	// new keys were applied, keyID is the encryption key.
+	applied bool
+	keyID   uint8
+	// rotated tells the encryption key changed from previousKeyID.
+	rotated       bool
+	previousKeyID uint8
+}
+
+func (x *XID) refresh(ctx context.Context) (refreshOutcome, error) {
+	cached := x.keys.get()
+	if cached.fresh(x.clock()) {
+		return refreshOutcome{fresh: true}, nil
+	}
+
+	resp, validators, err := x.getKeys(ctx, cached)
+	if errors.Is(err, ErrNotModified) {
+		x.keys.set(validators)
+
+		return refreshOutcome{notModified: true}, nil
+	}
+
 	if err != nil {
-		return err
+		return refreshOutcome{}, err
 	}
 
-	err = x.cryptoService.KeysRefresh(crypto.Keys{
+	keys := crypto.Keys{
 		Decryption: resp.Decryption,
 		Encryption: crypto.Encryption{
 			ID:    resp.Encryption.ID,
 			Value: resp.Encryption.Value,
 		},
-	})
+	}
+
+	err = x.cryptoService.KeysRefresh(keys)
 	if err != nil {
-		return fmt.Errorf("%s: %w", "keys refresh error", err)
+		return refreshOutcome{}, fmt.Errorf("%s: %w", "keys refresh error", err)
 	}
 
-	return nil
+	x.saveSnapshot(ctx, keys)
+
+	validators.keyID = resp.Encryption.ID
+	validators.hasKeys = true
+	x.keys.set(validators)
+
+	return refreshOutcome{
+		applied:       true,
+		keyID:         resp.Encryption.ID,
+		rotated:       cached.hasKeys && cached.keyID != resp.Encryption.ID,
+		previousKeyID: cached.keyID,
+	}, nil
 }
 
+// GetKeys fetches the current encryption keys. Unlike Refresh, the request is
+// unconditional, so the keys are always returned.
 func (x *XID) GetKeys(ctx context.Context) (KeysResp, error) {
-	resp, err := x.DoHTTPReq(ctx, http.MethodGet, x.baseURL.String()+KeysRefresh, nil)
+	ctx, span := x.startSpan(ctx, SpanGetKeys, tracing.String(tracing.AttrOperation, OpKeys))
+
+	resp, _, err := x.getKeys(ctx, keysCache{})
+	endSpan(span, err)
+
+	return resp, err
+}
+
+func (x *XID) getKeys(ctx context.Context, cached keysCache) (KeysResp, keysCache, error) {
+	resp, err := x.send(ctx, http.MethodGet, x.baseURL.String()+KeysRefresh, nil, func(req *http.Request) {
+		cached.conditional(req.Header)
+	})
 	if err != nil {
-		return KeysResp{}, fmt.Errorf("%w: %w", ErrDoHTTPReq, err)
+		return KeysResp{}, cached, fmt.Errorf("%w: %w", ErrDoHTTPReq, err)
+	}
+	defer drain(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return KeysResp{}, cached.revalidated(resp.Header, x.clock()), ErrNotModified
+	default:
+		return KeysResp{}, cached, fmt.Errorf("%w: %w", ErrDoHTTPReq, newAPIError(resp))
 	}
-	defer resp.Body.Close()
 
 	keyResp := KeysResp{}
 
-	err = json.NewDecoder(resp.Body).Decode(&keyResp)
+	err = x.decodeResponse(resp, &keyResp)
 	if err != nil {
-		return KeysResp{}, fmt.Errorf("%w: %w", ErrDecode, err)
+		return KeysResp{}, cached, err
+	}
+
+	return keyResp, newKeysCache(resp.Header, x.clock()), nil
+}
+
+func (x *XID) clock() time.Time {
+	if x.now == nil {
+		return time.Now()
 	}
 
-	return keyResp, nil
+	return x.now()
 }
@@ -10,6 +10,7 @@ import (
 	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/ceeideu/sdk/crypto"
 	"github.com/ceeideu/sdk/hem"
@@ -30,6 +31,7 @@ func TestXID_Send(t *testing.T) {
 	responseMock := func(resp xid.Response) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
 			m, _ := json.Marshal(resp)
+			w.Header().Set("Content-Type", "application/json")
 			_, _ = w.Write(m)
 		}
 	}
@@ -137,6 +139,7 @@ func TestXID_Refresh(t *testing.T) {
 					if err != nil {
 						panic(err)
 					}
+					writer.Header().Set("Content-Type", "application/json")
 					fmt.Fprint(writer, string(m))
 				},
 				cryptoService: &CryptoMock{
@@ -163,6 +166,7 @@ func TestXID_Refresh(t *testing.T) {
 					if err != nil {
 						panic(err)
 					}
+					writer.Header().Set("Content-Type", "application/json")
 					fmt.Fprint(writer, string(m))
 				},
 				cryptoService: &CryptoMock{
@@ -189,6 +193,69 @@ func TestXID_Refresh(t *testing.T) {
 	}
 }
 
+func TestXID_RefreshConditional(t *testing.T) {
+	t.Parallel()
+
+	var (
+		hits        int
+		ifNoneMatch string
+		cacheCtl    = "max-age=0"
+	)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, r *http.Request) {
+		hits++
+		ifNoneMatch = r.Header.Get(IfNoneMatchHeader)
+		writer.Header().Set(CacheControlHeader, cacheCtl)
+		if ifNoneMatch == `"v1"` {
+			writer.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+		writer.Header().Set(ETagHeader, `"v1"`)
+		m, _ := json.Marshal(KeysResp{Encryption: Encryption{ID: 1, Value: "foo"}})
+		writer.Header().Set("Content-Type", "application/json")
+		_, _ = writer.Write(m)
+	}))
+	defer testServer.Close()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cryptoMock := &CryptoMock{}
+	xidClient, _ := NewXID(testServer.URL, "foo", WithHTTPClient(testServer.Client()))
+	xidClient.cryptoService = cryptoMock
+	xidClient.now = func() time.Time { return now }
+
+	steps := []struct {
+		name            string
+		advance         time.Duration
+		cacheControl    string
+		wantHits        int
+		wantKeysCalls   int
+		wantIfNoneMatch string
+	}{
+		{name: "initial fetch", cacheControl: "max-age=0", wantHits: 1, wantKeysCalls: 1},
+		{name: "not modified", cacheControl: "max-age=60", wantHits: 2, wantKeysCalls: 1, wantIfNoneMatch: `"v1"`},
+		{name: "fresh", advance: 30 * time.Second, wantHits: 2, wantKeysCalls: 1, wantIfNoneMatch: `"v1"`},
+		{name: "stale", advance: time.Minute, cacheControl: "max-age=60", wantHits: 3, wantKeysCalls: 1, wantIfNoneMatch: `"v1"`},
+	}
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		cacheCtl = step.cacheControl
+		if err := xidClient.Refresh(context.Background()); err != nil {
+			t.Fatalf("[%s] Refresh() error = %v", step.name, err)
+		}
+		if hits != step.wantHits || cryptoMock.keysCalls != step.wantKeysCalls || ifNoneMatch != step.wantIfNoneMatch {
+			t.Errorf("[%s] hits = %d, keys calls = %d, If-None-Match = %q, want %d, %d, %q", step.name,
+				hits, cryptoMock.keysCalls, ifNoneMatch, step.wantHits, step.wantKeysCalls, step.wantIfNoneMatch)
+		}
+	}
+
+	// GetKeys ignores the validators of Refresh
+	keys, err := xidClient.GetKeys(context.Background())
+	if err != nil || keys.Encryption.ID != 1 || ifNoneMatch != "" {
+		t.Errorf("GetKeys() = %v, %v with If-None-Match %q, want the keys unconditionally", keys, err, ifNoneMatch)
+	}
+}
+
 func TestXID_RefreshXId(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -219,6 +286,7 @@ func TestXID_RefreshXId(t *testing.T) {
 				if err != nil {
 					panic(err)
 				}
+				writer.Header().Set("Content-Type", "application/json")
 				fmt.Fprint(writer, string(resp))
 			},
 			want: xid.RefreshResp{
@@ -230,6 +298,7 @@ func TestXID_RefreshXId(t *testing.T) {
 			name: "wrong format",
 			have: xid.RefreshReq{XID: "xid"},
 			handlerFunc: func(writer http.ResponseWriter, req *http.Request) {
+				writer.Header().Set("Content-Type", "application/json")
 				fmt.Fprint(writer, "bad format")
 			},
 			wantErr: true,
@@ -264,11 +333,12 @@ func TestXID_RefreshXId(t *testing.T) {
 }
 
 type CryptoMock struct {
-	encResp crypto.EncResp
-	encErr  error
-	decResp []byte
-	decErr  error
-	keysErr error
+	encResp   crypto.EncResp
+	encErr    error
+	decResp   []byte
+	decErr    error
+	keysErr   error
+	keysCalls int
 }
 
 func (m *CryptoMock) Encrypt(_ []byte) (crypto.EncResp, error) {
@@ -280,6 +350,8 @@ func (m *CryptoMock) Decrypt(_ uint8, _ []byte) ([]byte, error) {
 }
 
 func (m *CryptoMock) KeysRefresh(_ crypto.Keys) error {
+	m.keysCalls++
+
 	return m.keysErr
 }
 
@@ -452,3 +524,397 @@ func TestXID_TokenFromXID(t *testing.T) {
 		})
 	}
 }
+
+func TestXID_Map(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		have        xid.MapReq
+		handlerFunc http.HandlerFunc
+		want        xid.MapResp
+		wantErr     bool
+	}{
+		{
+			name: "valid flow",
+			have: xid.MapRequest(hem.FromHex("c9d394ff979740df69c329c50249e939ab05f25974d8c0ad66352c91ee5338ea").Identifier()),
+			handlerFunc: func(writer http.ResponseWriter, req *http.Request) {
+				if req.URL.Path != XidMap {
+					writer.WriteHeader(http.StatusNotFound)
+
+					return
+				}
+
+				var mapReq xid.MapReq
+				if err := json.NewDecoder(req.Body).Decode(&mapReq); err != nil {
+					panic(err)
+				}
+
+				mapResp := xid.MapResp{}
+				for _, identifier := range mapReq.Identifiers {
+					mapResp.Mappings = append(mapResp.Mappings, xid.Mapping{
+						Type:   identifier.Type,
+						Value:  identifier.Value,
+						XID:    "xid",
+						Status: xid.Okay,
+					})
+				}
+
+				resp, err := json.Marshal(mapResp)
+				if err != nil {
+					panic(err)
+				}
+				writer.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(writer, string(resp))
+			},
+			want: xid.MapResp{
+				Mappings: []xid.Mapping{
+					{
+						Type:   xid.Hex,
+						Value:  "c9d394ff979740df69c329c50249e939ab05f25974d8c0ad66352c91ee5338ea",
+						XID:    "xid",
+						Status: xid.Okay,
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "wrong format",
+			have: xid.MapRequest(xid.Identifier{Type: xid.Hex, Value: "foo"}),
+			handlerFunc: func(writer http.ResponseWriter, req *http.Request) {
+				writer.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(writer, "bad format")
+			},
+			wantErr: true,
+		},
+		{
+			name: "error from /xid/map endpoint",
+			have: xid.MapRequest(xid.Identifier{Type: xid.Hex, Value: "foo"}),
+			handlerFunc: func(writer http.ResponseWriter, req *http.Request) {
+				writer.WriteHeader(http.StatusInternalServerError)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			testServer := httptest.NewServer(test.handlerFunc)
+			defer testServer.Close()
+			xidClient, _ := NewXID(testServer.URL, "foo", WithHTTPClient(testServer.Client()))
+
+			got, err := xidClient.Map(context.Background(), test.have)
+			if (err != nil) != test.wantErr {
+				t.Errorf("[%s] XID.Map() error = %v, wantErr %v", test.name, err, test.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("[%s] XID.Map() = %v, want %v", test.name, got, test.want)
+			}
+		})
+	}
+}
+
+func TestXID_Lookup(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		have        xid.LookupReq
+		handlerFunc http.HandlerFunc
+		want        xid.LookupResp
+		wantActive  bool
+		wantErr     bool
+	}{
+		{
+			name: "active",
+			have: xid.LookupRequest("xid"),
+			handlerFunc: func(writer http.ResponseWriter, req *http.Request) {
+				var lookupReq xid.LookupReq
+				if err := json.NewDecoder(req.Body).Decode(&lookupReq); err != nil {
+					panic(err)
+				}
+				resp, _ := json.Marshal(xid.LookupResp{XID: lookupReq.XID, Known: true, Status: xid.Okay})
+				writer.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(writer, string(resp))
+			},
+			want:       xid.LookupResp{XID: "xid", Known: true, Status: xid.Okay},
+			wantActive: true,
+		},
+		{
+			name: "blocked",
+			have: xid.LookupRequest("xid"),
+			handlerFunc: func(writer http.ResponseWriter, req *http.Request) {
+				resp, _ := json.Marshal(xid.LookupResp{XID: "xid", Known: true, Status: xid.UserBlocked})
+				writer.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(writer, string(resp))
+			},
+			want:       xid.LookupResp{XID: "xid", Known: true, Status: xid.UserBlocked},
+			wantActive: false,
+		},
+		{
+			name: "error from /xid/lookup endpoint",
+			have: xid.LookupRequest("xid"),
+			handlerFunc: func(writer http.ResponseWriter, req *http.Request) {
+				writer.WriteHeader(http.StatusInternalServerError)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			testServer := httptest.NewServer(test.handlerFunc)
+			defer testServer.Close()
+			xidClient, _ := NewXID(testServer.URL, "foo", WithHTTPClient(testServer.Client()))
+
+			got, err := xidClient.Lookup(context.Background(), test.have)
+			if (err != nil) != test.wantErr {
+				t.Errorf("[%s] XID.Lookup() error = %v, wantErr %v", test.name, err, test.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("[%s] XID.Lookup() = %v, want %v", test.name, got, test.want)
+			}
+
+			if got.Active() != test.wantActive {
+				t.Errorf("[%s] LookupResp.Active() = %v, want %v", test.name, got.Active(), test.wantActive)
+			}
+		})
+	}
+}
+
+func TestXID_DecodeToken(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		mode        DecodeMode
+		token       xid.Token
+		handlerFunc http.HandlerFunc
+		crypto      Crypto
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:  "remote",
+			mode:  DecodeModeRemote,
+			token: xid.NewToken(2, xid.Value("foo")),
+			handlerFunc: func(writer http.ResponseWriter, req *http.Request) {
+				var decodeReq xid.DecodeReq
+				if err := json.NewDecoder(req.Body).Decode(&decodeReq); err != nil || req.URL.Path != XidDecode {
+					writer.WriteHeader(http.StatusBadRequest)
+
+					return
+				}
+				resp, _ := json.Marshal(xid.DecodeResp{Value: "decoded " + decodeReq.Token})
+				writer.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(writer, string(resp))
+			},
+			crypto: &CryptoMock{decErr: Err},
+			want:   "decoded " + xid.NewToken(2, xid.Value("foo")).String(),
+		},
+		{
+			name:  "remote error",
+			mode:  DecodeModeRemote,
+			token: xid.NewToken(2, xid.Value("foo")),
+			handlerFunc: func(writer http.ResponseWriter, req *http.Request) {
+				writer.WriteHeader(http.StatusForbidden)
+			},
+			crypto:  &CryptoMock{decResp: []byte("ok")},
+			wantErr: true,
+		},
+		{
+			name:  "local",
+			mode:  DecodeModeLocal,
+			token: xid.NewToken(2, xid.Value("foo")),
+			handlerFunc: func(writer http.ResponseWriter, req *http.Request) {
+				writer.WriteHeader(http.StatusInternalServerError)
+			},
+			crypto: &CryptoMock{decResp: []byte("ok")},
+			want:   "ok",
+		},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			testServer := httptest.NewServer(test.handlerFunc)
+			defer testServer.Close()
+			xidClient, _ := NewXID(testServer.URL, "foo", WithHTTPClient(testServer.Client()), WithDecodeMode(test.mode))
+			xidClient.cryptoService = test.crypto
+
+			got, err := xidClient.DecodeToken(context.Background(), test.token)
+			if (err != nil) != test.wantErr {
+				t.Errorf("[%s] XID.DecodeToken() error = %v, wantErr %v", test.name, err, test.wantErr)
+			}
+
+			if got != test.want {
+				t.Errorf("[%s] XID.DecodeToken() = %v, want %v", test.name, got, test.want)
+			}
+		})
+	}
+}
+
+func TestXID_IssueToken(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		hemReq      hem.Request
+		handlerFunc http.HandlerFunc
+		want        xid.Token
+		wantErr     bool
+	}{
+		{
+			name:   "ok",
+			hemReq: hem.FromEmail("test@com"),
+			handlerFunc: func(writer http.ResponseWriter, req *http.Request) {
+				if req.URL.Path != Token {
+					writer.WriteHeader(http.StatusNotFound)
+
+					return
+				}
+				resp, _ := json.Marshal(xid.TokenResponse{Value: "1token"})
+				writer.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(writer, string(resp))
+			},
+			want: xid.Token("1token"),
+		},
+		{
+			name:        "hem error",
+			hemReq:      hem.FromHex("foo"),
+			handlerFunc: func(writer http.ResponseWriter, req *http.Request) {},
+			wantErr:     true,
+		},
+		{
+			name:   "error from /token endpoint",
+			hemReq: hem.FromEmail("test@com"),
+			handlerFunc: func(writer http.ResponseWriter, req *http.Request) {
+				writer.WriteHeader(http.StatusInternalServerError)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			testServer := httptest.NewServer(test.handlerFunc)
+			defer testServer.Close()
+			xidClient, _ := NewXID(testServer.URL, "foo", WithHTTPClient(testServer.Client()))
+
+			got, err := xidClient.IssueToken(context.Background(), test.hemReq)
+			if (err != nil) != test.wantErr {
+				t.Errorf("[%s] XID.IssueToken() error = %v, wantErr %v", test.name, err, test.wantErr)
+			}
+
+			if got != test.want {
+				t.Errorf("[%s] XID.IssueToken() = %v, want %v", test.name, got, test.want)
+			}
+		})
+	}
+}
+
+func TestXID_RefreshToken(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		have        xid.TokenRefreshReq
+		handlerFunc http.HandlerFunc
+		want        xid.Token
+		wantErr     bool
+	}{
+		{
+			name: "ok",
+			have: xid.TokenRefreshRequest("xid"),
+			handlerFunc: func(writer http.ResponseWriter, req *http.Request) {
+				var refreshReq xid.TokenRefreshReq
+				if err := json.NewDecoder(req.Body).Decode(&refreshReq); err != nil || req.URL.Path != TokenRefresh {
+					writer.WriteHeader(http.StatusBadRequest)
+
+					return
+				}
+				resp, _ := json.Marshal(xid.TokenRefreshResp{Token: "1" + refreshReq.XID})
+				writer.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(writer, string(resp))
+			},
+			want: xid.Token("1xid"),
+		},
+		{
+			name: "wrong format",
+			have: xid.TokenRefreshRequest("xid"),
+			handlerFunc: func(writer http.ResponseWriter, req *http.Request) {
+				writer.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(writer, "bad format")
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			testServer := httptest.NewServer(test.handlerFunc)
+			defer testServer.Close()
+			xidClient, _ := NewXID(testServer.URL, "foo", WithHTTPClient(testServer.Client()))
+
+			got, err := xidClient.RefreshToken(context.Background(), test.have)
+			if (err != nil) != test.wantErr {
+				t.Errorf("[%s] XID.RefreshToken() error = %v, wantErr %v", test.name, err, test.wantErr)
+			}
+
+			if got != test.want {
+				t.Errorf("[%s] XID.RefreshToken() = %v, want %v", test.name, got, test.want)
+			}
+		})
+	}
+}
+
+func TestXID_SendStatus(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		status  string
+		wantErr error
+	}{
+		{name: "ok", status: xid.Okay, wantErr: nil},
+		{name: "unknown", status: "", wantErr: nil},
+		{name: "blocked", status: xid.UserBlocked, wantErr: ErrUserBlocked},
+		{name: "consent", status: xid.InvalidConsent, wantErr: ErrConsent},
+	}
+	for _, tt := range tests {
+		test := tt
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				m, _ := json.Marshal(xid.Response{Value: "xid", Status: test.status})
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write(m)
+			}))
+			defer ts.Close()
+
+			xidClient, _ := NewXID(ts.URL, XApiMockValue, WithHTTPClient(ts.Client()))
+
+			got, err := xidClient.Send(context.Background(), hem.FromEmail("test@com"))
+			if !errors.Is(err, test.wantErr) || (err == nil) != (test.wantErr == nil) {
+				t.Errorf("XID.Send() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if got.Status != test.status {
+				t.Errorf("XID.Send() status = %v, want %v", got.Status, test.status)
+			}
+
+			refreshed, err := xidClient.RefreshXID(context.Background(), xid.RefreshRequest("xid"))
+			if !errors.Is(err, test.wantErr) || (err == nil) != (test.wantErr == nil) {
+				t.Errorf("XID.RefreshXID() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if refreshed.Status != test.status {
+				t.Errorf("XID.RefreshXID() status = %v, want %v", refreshed.Status, test.status)
+			}
+		})
+	}
+}